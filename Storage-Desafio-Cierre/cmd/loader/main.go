@@ -0,0 +1,81 @@
+package main
+
+import (
+	"app/internal/application"
+	"app/internal/loader"
+	"app/internal/repository"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata"
+)
+
+func main() {
+	// env
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	dir := fs.String("dir", "docs/db/json", "directory with the json fixtures")
+	// - config: defaults < config file < environment < flags, as the server
+	cfg, err := application.LoadConfigApplicationDefaultWithFlags(fs, os.Args[1:], os.LookupEnv)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
+	// run
+	err = run(cfg, *dir)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Println("fixtures loaded from:", *dir)
+}
+
+// run opens the database of cfg and loads the fixtures of dir into it.
+func run(cfg *application.ConfigApplicationDefault, dir string) (err error) {
+	// db
+	cfg.Db.Loc = cfg.TimeZone
+	db, err := sql.Open("mysql", cfg.Db.FormatDSN())
+	if err != nil {
+		return
+	}
+	defer db.Close()
+
+	// load: SIGINT/SIGTERM cancels it and rolls it back
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = load(ctx, db, dir, cfg.TimeZone)
+	return
+}
+
+// load saves the fixtures of dir in a single transaction, reading datetimes in the time zone loc.
+func load(ctx context.Context, db *sql.DB, dir string, loc *time.Location) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// - repository
+	rpCustomer := repository.NewCustomersMySQL(tx)
+	rpProduct := repository.NewProductsMySQL(tx)
+	rpInvoice := repository.NewInvoicesMySQL(tx)
+	rpSale := repository.NewSalesMySQL(tx)
+	// - loader
+	ld := loader.NewLoaderJSON(dir, loc, rpCustomer, rpProduct, rpInvoice, rpSale)
+	err = ld.Load(ctx)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
@@ -2,38 +2,39 @@ package main
 
 import (
 	"app/internal/application"
+	"context"
 	"fmt"
-
-	"github.com/go-sql-driver/mysql"
+	"os"
+	_ "time/tzdata"
 )
 
 func main() {
 	// env
-	// ...
+	// - config: defaults < config file < environment < flags
+	cfg, err := application.LoadConfigApplicationDefault(os.Args[1:], os.LookupEnv)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
 
 	// app
-	// - config
-	cfg := &application.ConfigApplicationDefault{
-		Db: &mysql.Config{
-			User:                 "root",
-			Passwd:               "root",
-			Net:                  "tcp",
-			Addr:                 "localhost:3306",
-			DBName:               "fantasy_products",
-		},
-		Addr: "127.0.0.1:8080",
-	}
 	app := application.NewApplicationDefault(cfg)
+	// - tear down
+	defer func() {
+		if err := app.TearDown(); err != nil {
+			fmt.Println(err)
+		}
+	}()
 	// - set up
-	err := app.SetUp()
+	err = app.SetUp()
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	// - run
-	err = app.Run()
+	// - run: until SIGINT or SIGTERM
+	err = app.Run(context.Background())
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-}
\ No newline at end of file
+}
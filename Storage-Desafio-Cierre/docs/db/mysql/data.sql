@@ -34,12 +34,12 @@ INSERT INTO `products` (`description`,`price`) VALUES
 ('Product 8',800.00);
 
 -- Add data to table sales
-INSERT INTO `sales` (`quantity`,`invoice_id`,`product_id`) VALUES
-(1,1,1),
-(2,2,2),
-(3,3,3),
-(4,4,4),
-(5,5,5),
-(6,6,6),
-(7,7,7),
-(8,8,8);
\ No newline at end of file
+INSERT INTO `sales` (`quantity`,`invoice_id`,`product_id`,`unit_price`) VALUES
+(1,1,1,100.00),
+(2,2,2,200.00),
+(3,3,3,300.00),
+(4,4,4,400.00),
+(5,5,5,500.00),
+(6,6,6,600.00),
+(7,7,7,700.00),
+(8,8,8,800.00);
\ No newline at end of file
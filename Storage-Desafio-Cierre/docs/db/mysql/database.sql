@@ -14,12 +14,25 @@ CREATE TABLE `customers` (
     PRIMARY KEY (`id`)
 );
 
+-- Table structure for table `customer_condition_changes`
+CREATE TABLE `customer_condition_changes` (
+    `id` int NOT NULL AUTO_INCREMENT,
+    `customer_id` int NOT NULL,
+    `previous` tinyint(1) NOT NULL,
+    `condition` tinyint(1) NOT NULL,
+    `changed_by` varchar(45) DEFAULT NULL,
+    `datetime` datetime NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_customer_condition_changes_customer_id` (`customer_id`, `datetime`),
+    CONSTRAINT `fk_customer_condition_changes_customer_id` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
 -- Table structure for table `invoices`
 CREATE TABLE `invoices` (
     `id` int NOT NULL AUTO_INCREMENT,
     `datetime` datetime DEFAULT NULL,
     `customer_id` int DEFAULT NULL,
-    `total` float DEFAULT NULL,
+    `total` decimal(12,2) DEFAULT NULL,
     PRIMARY KEY (`id`),
     KEY `idx_invoices_customer_id` (`customer_id`),
     CONSTRAINT `fk_invoices_customer_id` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
@@ -29,7 +42,8 @@ CREATE TABLE `invoices` (
 CREATE TABLE `products` (
     `id` int NOT NULL AUTO_INCREMENT,
     `description` varchar(100) DEFAULT NULL,
-    `price` float DEFAULT NULL,
+    `price` decimal(12,2) DEFAULT NULL,
+    `stock` int NOT NULL DEFAULT 0,
     PRIMARY KEY (`id`)
 );
 
@@ -39,9 +53,24 @@ CREATE TABLE `sales` (
     `quantity` int DEFAULT NULL,
     `invoice_id` int DEFAULT NULL,
     `product_id` int DEFAULT NULL,
+    `unit_price` decimal(12,2) NOT NULL DEFAULT 0,
     PRIMARY KEY (`id`),
     KEY `idx_sales_invoice_id` (`invoice_id`),
     KEY `idx_sales_product_id` (`product_id`),
     CONSTRAINT `fk_sales_invoice_id` FOREIGN KEY (`invoice_id`) REFERENCES `invoices` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
     CONSTRAINT `fk_sales_product_id` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
-);
\ No newline at end of file
+);
+
+-- Table structure for table `stock_movements`
+CREATE TABLE `stock_movements` (
+    `id` int NOT NULL AUTO_INCREMENT,
+    `product_id` int NOT NULL,
+    `quantity` int NOT NULL,
+    `reason` varchar(45) NOT NULL,
+    `sale_id` int DEFAULT NULL,
+    `balance` int NOT NULL,
+    `datetime` datetime NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_stock_movements_product_id` (`product_id`),
+    CONSTRAINT `fk_stock_movements_product_id` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
@@ -0,0 +1,7 @@
+-- Migration: store money as DECIMAL(12,2) instead of float
+USE `fantasy_products`;
+
+-- float values are rounded to cents by the conversion
+ALTER TABLE `products` MODIFY `price` decimal(12,2) DEFAULT NULL;
+ALTER TABLE `invoices` MODIFY `total` decimal(12,2) DEFAULT NULL;
+
@@ -0,0 +1,33 @@
+-- Migration: track the stock of products with a ledger of stock movements and keep the price they are sold at
+--
+-- Existing products start with a stock of @initial_stock, 100 unless it is set before, e.g.
+--   mysql --init-command="SET @initial_stock = 500" < 002_stock.sql
+-- recorded as an initial stock movement. Adjust each one later through POST /products/{id}/stock.
+USE `fantasy_products`;
+
+SET @initial_stock = COALESCE(@initial_stock, 100);
+
+ALTER TABLE `products` ADD `stock` int NOT NULL DEFAULT 0;
+
+-- sale_id has no foreign key so the movements of deleted sales are kept
+CREATE TABLE `stock_movements` (
+    `id` int NOT NULL AUTO_INCREMENT,
+    `product_id` int NOT NULL,
+    `quantity` int NOT NULL,
+    `reason` varchar(45) NOT NULL,
+    `sale_id` int DEFAULT NULL,
+    `balance` int NOT NULL,
+    `datetime` datetime NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_stock_movements_product_id` (`product_id`),
+    CONSTRAINT `fk_stock_movements_product_id` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- existing products start with the initial stock, the balance of their first movement
+UPDATE `products` SET `stock` = @initial_stock;
+INSERT INTO `stock_movements` (`product_id`, `quantity`, `reason`, `sale_id`, `balance`, `datetime`)
+SELECT `id`, @initial_stock, 'initial', NULL, @initial_stock, NOW() FROM `products` WHERE @initial_stock <> 0;
+
+-- existing sales take the current price of their product, the best known approximation of the price they were sold at
+ALTER TABLE `sales` ADD `unit_price` decimal(12,2) NOT NULL DEFAULT 0;
+UPDATE `sales` s INNER JOIN `products` p ON p.`id` = s.`product_id` SET s.`unit_price` = COALESCE(p.`price`, 0);
@@ -0,0 +1,15 @@
+-- Migration: keep the history of the customer conditions
+USE `fantasy_products`;
+
+-- customers without changes keep their current condition at any point in time
+CREATE TABLE `customer_condition_changes` (
+    `id` int NOT NULL AUTO_INCREMENT,
+    `customer_id` int NOT NULL,
+    `previous` tinyint(1) NOT NULL,
+    `condition` tinyint(1) NOT NULL,
+    `changed_by` varchar(45) DEFAULT NULL,
+    `datetime` datetime NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_customer_condition_changes_customer_id` (`customer_id`, `datetime`),
+    CONSTRAINT `fk_customer_condition_changes_customer_id` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
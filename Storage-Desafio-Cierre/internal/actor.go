@@ -0,0 +1,17 @@
+package internal
+
+import "context"
+
+// actorKey is the context key of the actor.
+type actorKey struct{}
+
+// WithActor returns a copy of ctx with the actor, who makes the changes of the request, e.g. a user name.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor of ctx, or empty if it has none.
+func ActorFromContext(ctx context.Context) (actor string) {
+	actor, _ = ctx.Value(actorKey{}).(string)
+	return
+}
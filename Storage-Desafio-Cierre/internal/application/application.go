@@ -1,9 +1,13 @@
 package application
 
+import "context"
+
 // Application is an interface that represents an application.
 type Application interface {
-	// Run runs the application.
-	Run() (err error)
+	// Run runs the application until ctx is done.
+	Run(ctx context.Context) (err error)
 	// SetUp sets up the application.
 	SetUp() (err error)
-}
\ No newline at end of file
+	// TearDown releases the resources of the application.
+	TearDown() (err error)
+}
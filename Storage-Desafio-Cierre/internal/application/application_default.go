@@ -1,12 +1,21 @@
 package application
 
 import (
+	"app/internal"
 	"app/internal/handler"
+	"app/internal/loader"
 	"app/internal/repository"
+	"app/internal/repository/memory"
 	"app/internal/service"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -17,16 +26,59 @@ import (
 type ConfigApplicationDefault struct {
 	// Db is the database configuration.
 	Db *mysql.Config
+	// DbMaxOpenConns is the maximum number of open connections, zero means unlimited.
+	DbMaxOpenConns int
+	// DbMaxIdleConns is the maximum number of idle connections, zero keeps the driver default.
+	DbMaxIdleConns int
+	// DbConnMaxLifetime is the maximum time a connection is reused, zero means forever.
+	DbConnMaxLifetime time.Duration
 	// Addr is the server address.
 	Addr string
+	// ServerReadTimeout is the maximum time to read a request, zero means no timeout.
+	ServerReadTimeout time.Duration
+	// ServerWriteTimeout is the maximum time to write a response, zero means no timeout.
+	ServerWriteTimeout time.Duration
+	// ServerIdleTimeout is the maximum time to keep an idle connection, zero uses ServerReadTimeout.
+	ServerIdleTimeout time.Duration
+	// RequestTimeout is the maximum time to handle a request, its queries are cancelled after it.
+	// Zero means no timeout.
+	RequestTimeout time.Duration
+	// ShutdownTimeout is the maximum time Run waits for in-flight requests when stopping.
+	ShutdownTimeout time.Duration
+	// TimeZone is the store time zone: datetimes are read and written in it by the database
+	// and invoice datetimes without an offset are parsed in it. Nil means UTC.
+	TimeZone *time.Location
+	// Storage is the repositories backend, either StorageMySQL or StorageMemory.
+	Storage string
+	// Fixtures is the directory of json fixtures loaded into the memory storage.
+	// It is ignored for other storages and the memory storage starts empty if it is not set.
+	Fixtures string
+	// Templates is the directory of *.html templates that override the default ones of the invoice documents.
+	// The default templates are used if it is not set.
+	Templates string
 }
 
+const (
+	// StorageMySQL keeps the data in the MySQL database of Db.
+	StorageMySQL = "mysql"
+	// StorageMemory keeps the data in memory, so it is lost when the application stops.
+	StorageMemory = "memory"
+)
+
+var (
+	// ErrStorageUnknown is returned when the storage of the configuration is not supported.
+	ErrStorageUnknown = errors.New("application: unknown storage")
+)
+
 // NewApplicationDefault creates a new ApplicationDefault.
 func NewApplicationDefault(config *ConfigApplicationDefault) *ApplicationDefault {
 	// default values
 	defaultCfg := &ConfigApplicationDefault{
-		Db:      nil,
-		Addr: ":8080",
+		Db:              nil,
+		Addr:            ":8080",
+		ShutdownTimeout: 15 * time.Second,
+		TimeZone:        time.UTC,
+		Storage:         StorageMySQL,
 	}
 	if config != nil {
 		if config.Db != nil {
@@ -35,11 +87,41 @@ func NewApplicationDefault(config *ConfigApplicationDefault) *ApplicationDefault
 		if config.Addr != "" {
 			defaultCfg.Addr = config.Addr
 		}
+		defaultCfg.DbMaxOpenConns = config.DbMaxOpenConns
+		defaultCfg.DbMaxIdleConns = config.DbMaxIdleConns
+		defaultCfg.DbConnMaxLifetime = config.DbConnMaxLifetime
+		defaultCfg.ServerReadTimeout = config.ServerReadTimeout
+		defaultCfg.ServerWriteTimeout = config.ServerWriteTimeout
+		defaultCfg.ServerIdleTimeout = config.ServerIdleTimeout
+		defaultCfg.RequestTimeout = config.RequestTimeout
+		if config.ShutdownTimeout != 0 {
+			defaultCfg.ShutdownTimeout = config.ShutdownTimeout
+		}
+		if config.TimeZone != nil {
+			defaultCfg.TimeZone = config.TimeZone
+		}
+		if config.Storage != "" {
+			defaultCfg.Storage = config.Storage
+		}
+		defaultCfg.Fixtures = config.Fixtures
+		defaultCfg.Templates = config.Templates
 	}
 
 	return &ApplicationDefault{
-		cfgDb:      defaultCfg.Db,
-		cfgAddr: defaultCfg.Addr,
+		cfgDb:                 defaultCfg.Db,
+		cfgDbMaxOpenConns:     defaultCfg.DbMaxOpenConns,
+		cfgDbMaxIdleConns:     defaultCfg.DbMaxIdleConns,
+		cfgDbConnMaxLifetime:  defaultCfg.DbConnMaxLifetime,
+		cfgAddr:               defaultCfg.Addr,
+		cfgServerReadTimeout:  defaultCfg.ServerReadTimeout,
+		cfgServerWriteTimeout: defaultCfg.ServerWriteTimeout,
+		cfgServerIdleTimeout:  defaultCfg.ServerIdleTimeout,
+		cfgRequestTimeout:     defaultCfg.RequestTimeout,
+		cfgShutdownTimeout:    defaultCfg.ShutdownTimeout,
+		cfgTimeZone:           defaultCfg.TimeZone,
+		cfgStorage:            defaultCfg.Storage,
+		cfgFixtures:           defaultCfg.Fixtures,
+		cfgTemplates:          defaultCfg.Templates,
 	}
 }
 
@@ -47,8 +129,32 @@ func NewApplicationDefault(config *ConfigApplicationDefault) *ApplicationDefault
 type ApplicationDefault struct {
 	// cfgDb is the database configuration.
 	cfgDb *mysql.Config
+	// cfgDbMaxOpenConns is the maximum number of open connections.
+	cfgDbMaxOpenConns int
+	// cfgDbMaxIdleConns is the maximum number of idle connections.
+	cfgDbMaxIdleConns int
+	// cfgDbConnMaxLifetime is the maximum time a connection is reused.
+	cfgDbConnMaxLifetime time.Duration
 	// cfgAddr is the server address.
 	cfgAddr string
+	// cfgServerReadTimeout is the maximum time to read a request.
+	cfgServerReadTimeout time.Duration
+	// cfgServerWriteTimeout is the maximum time to write a response.
+	cfgServerWriteTimeout time.Duration
+	// cfgServerIdleTimeout is the maximum time to keep an idle connection.
+	cfgServerIdleTimeout time.Duration
+	// cfgRequestTimeout is the maximum time to handle a request.
+	cfgRequestTimeout time.Duration
+	// cfgShutdownTimeout is the maximum time to wait for in-flight requests when stopping.
+	cfgShutdownTimeout time.Duration
+	// cfgTimeZone is the store time zone.
+	cfgTimeZone *time.Location
+	// cfgStorage is the repositories backend.
+	cfgStorage string
+	// cfgFixtures is the directory of json fixtures for the memory storage.
+	cfgFixtures string
+	// cfgTemplates is the directory of templates of the invoice documents.
+	cfgTemplates string
 	// db is the database connection.
 	db *sql.DB
 	// router is the chi router.
@@ -58,31 +164,73 @@ type ApplicationDefault struct {
 // SetUp sets up the application.
 func (a *ApplicationDefault) SetUp() (err error) {
 	// dependencies
-	// - db: init
-	a.db, err = sql.Open("mysql", a.cfgDb.FormatDSN())
-	if err != nil {
-		return
-	}
-	// - db: ping
-	err = a.db.Ping()
-	if err != nil {
+	// - repository
+	var rpCustomer internal.RepositoryCustomer
+	var rpProduct internal.RepositoryProduct
+	var rpInvoice internal.RepositoryInvoice
+	var rpSale internal.RepositorySale
+	var rpReport internal.RepositoryReport
+	switch a.cfgStorage {
+	case StorageMySQL:
+		// - db: init, datetimes are scanned as time.Time in the store time zone
+		a.cfgDb.ParseTime = true
+		a.cfgDb.Loc = a.cfgTimeZone
+		a.db, err = sql.Open("mysql", a.cfgDb.FormatDSN())
+		if err != nil {
+			return
+		}
+		// - db: pool
+		a.db.SetMaxOpenConns(a.cfgDbMaxOpenConns)
+		if a.cfgDbMaxIdleConns > 0 {
+			a.db.SetMaxIdleConns(a.cfgDbMaxIdleConns)
+		}
+		a.db.SetConnMaxLifetime(a.cfgDbConnMaxLifetime)
+		// - db: ping
+		err = a.db.Ping()
+		if err != nil {
+			return
+		}
+		rpCustomer = repository.NewCustomersMySQL(a.db)
+		rpProduct = repository.NewProductsMySQL(a.db)
+		rpInvoice = repository.NewInvoicesMySQL(a.db)
+		rpSale = repository.NewSalesMySQL(a.db)
+		rpReport = repository.NewReportsMySQL(a.db)
+	case StorageMemory:
+		st := memory.NewStore()
+		rpCustomer = memory.NewCustomersMemory(st)
+		rpProduct = memory.NewProductsMemory(st)
+		rpInvoice = memory.NewInvoicesMemory(st)
+		rpSale = memory.NewSalesMemory(st)
+		rpReport = memory.NewReportsMemory(st)
+		// - fixtures
+		if a.cfgFixtures != "" {
+			ld := loader.NewLoaderJSON(a.cfgFixtures, a.cfgTimeZone, rpCustomer, rpProduct, rpInvoice, rpSale)
+			err = ld.Load(context.Background())
+			if err != nil {
+				return
+			}
+		}
+	default:
+		err = fmt.Errorf("%w: %s", ErrStorageUnknown, a.cfgStorage)
 		return
 	}
-	// - repository
-	rpCustomer := repository.NewCustomersMySQL(a.db)
-	rpProduct := repository.NewProductsMySQL(a.db)
-	rpInvoice := repository.NewInvoicesMySQL(a.db)
-	rpSale := repository.NewSalesMySQL(a.db)
 	// - service
 	svCustomer := service.NewCustomersDefault(rpCustomer)
 	svProduct := service.NewProductsDefault(rpProduct)
 	svInvoice := service.NewInvoicesDefault(rpInvoice)
 	svSale := service.NewSalesDefault(rpSale)
+	svReport := service.NewReportsDefault(rpReport)
 	// - handler
-	hdCustomer := handler.NewCustomersDefault(svCustomer)
-	hdProduct := handler.NewProductsDefault(svProduct)
-	hdInvoice := handler.NewInvoicesDefault(svInvoice)
+	hdCustomer := handler.NewCustomersDefault(svCustomer, a.cfgTimeZone)
+	hdProduct := handler.NewProductsDefault(svProduct, a.cfgTimeZone)
+	hdInvoice := handler.NewInvoicesDefault(svInvoice, a.cfgTimeZone)
+	tpl, err := handler.ParseTemplates(a.cfgTemplates)
+	if err != nil {
+		return
+	}
+	hdInvoice.SetTemplates(tpl)
 	hdSale := handler.NewSalesDefault(svSale)
+	hdReport := handler.NewReportsDefault(svReport, a.cfgTimeZone)
 
 	// routes
 	// - router
@@ -90,12 +238,30 @@ func (a *ApplicationDefault) SetUp() (err error) {
 	// - middlewares
 	a.router.Use(middleware.Logger)
 	a.router.Use(middleware.Recoverer)
+	a.router.Use(handler.Timeout(a.cfgRequestTimeout))
+	a.router.Use(handler.Actor)
 	// - endpoints
 	a.router.Route("/customers", func(r chi.Router) {
 		// - GET /customers
 		r.Get("/", hdCustomer.GetAll())
 		// - POST /customers
 		r.Post("/", hdCustomer.Create())
+		// - POST /customers/import
+		r.Post("/import", hdCustomer.Import())
+		// - GET /customers/{id}
+		r.Get("/{id}", hdCustomer.GetById())
+		// - PUT /customers/{id}
+		r.Put("/{id}", hdCustomer.Update())
+		// - PATCH /customers/{id}
+		r.Patch("/{id}", hdCustomer.Patch())
+		// - DELETE /customers/{id}
+		r.Delete("/{id}", hdCustomer.Delete())
+		// - POST /customers/{id}/activate
+		r.Post("/{id}/activate", hdCustomer.Activate())
+		// - POST /customers/{id}/deactivate
+		r.Post("/{id}/deactivate", hdCustomer.Deactivate())
+		// - GET /customers/{id}/condition-history
+		r.Get("/{id}/condition-history", hdCustomer.GetConditionHistory())
 
 		r.Get("/top-active", hdCustomer.GetTopActiveCustomersByAmountSpent())
 		r.Get("/invoices-by-condition", hdCustomer.GetInvoicesByCondition())
@@ -105,7 +271,25 @@ func (a *ApplicationDefault) SetUp() (err error) {
 		r.Get("/", hdProduct.GetAll())
 		// - POST /products
 		r.Post("/", hdProduct.Create())
+		// - POST /products/import
+		r.Post("/import", hdProduct.Import())
 		r.Get("/top-sold", hdProduct.GetTopProducts())
+		// - GET /products/low-stock
+		r.Get("/low-stock", hdProduct.GetLowStock())
+		// - GET /products/{id}
+		r.Get("/{id}", hdProduct.GetById())
+		// - PUT /products/{id}
+		r.Put("/{id}", hdProduct.Update())
+		// - PATCH /products/{id}
+		r.Patch("/{id}", hdProduct.Patch())
+		// - DELETE /products/{id}
+		r.Delete("/{id}", hdProduct.Delete())
+		// - POST /products/{id}/stock
+		r.Post("/{id}/stock", hdProduct.AdjustStock())
+		// - GET /products/{id}/stock/movements
+		r.Get("/{id}/stock/movements", hdProduct.GetStockMovements())
+		// - GET /products/{id}/bought-with
+		r.Get("/{id}/bought-with", hdProduct.GetBoughtWith())
 	})
 	a.router.Route("/invoices", func(r chi.Router) {
 		// - GET /invoices
@@ -113,22 +297,93 @@ func (a *ApplicationDefault) SetUp() (err error) {
 		// - POST /invoices
 		r.Post("/", hdInvoice.Create())
 		r.Put("/total", hdInvoice.UpdateTotal())
+		// - GET /invoices/{id}
+		r.Get("/{id}", hdInvoice.GetById())
+		// - PUT /invoices/{id}
+		r.Put("/{id}", hdInvoice.Update())
+		// - PATCH /invoices/{id}
+		r.Patch("/{id}", hdInvoice.Patch())
+		// - DELETE /invoices/{id}
+		r.Delete("/{id}", hdInvoice.Delete())
+		// - GET /invoices/{id}/document
+		r.Get("/{id}/document", hdInvoice.GetDocument())
+		// - PUT /invoices/{id}/total
+		r.Put("/{id}/total", hdInvoice.UpdateTotalById())
 	})
+	// - POST /checkout
+	a.router.Post("/checkout", hdInvoice.Checkout())
 	a.router.Route("/sales", func(r chi.Router) {
 		// - GET /sales
 		r.Get("/", hdSale.GetAll())
 		// - POST /sales
 		r.Post("/", hdSale.Create())
+		// - GET /sales/{id}
+		r.Get("/{id}", hdSale.GetById())
+		// - PUT /sales/{id}
+		r.Put("/{id}", hdSale.Update())
+		// - PATCH /sales/{id}
+		r.Patch("/{id}", hdSale.Patch())
+		// - DELETE /sales/{id}
+		r.Delete("/{id}", hdSale.Delete())
+	})
+	a.router.Route("/reports", func(r chi.Router) {
+		// - GET /reports/revenue
+		r.Get("/revenue", hdReport.GetRevenue())
+		// - GET /reports/customers/rfm
+		r.Get("/customers/rfm", hdReport.GetRFM())
+		// - GET /reports/products/associations
+		r.Get("/products/associations", hdProduct.GetAssociations())
 	})
 
 	return
 }
 
-// Run runs the application.
-func (a *ApplicationDefault) Run() (err error) {
-	defer a.db.Close()
+// Run runs the application until ctx is done or SIGINT/SIGTERM is received,
+// then it stops accepting connections and waits for the in-flight requests up to the shutdown timeout.
+func (a *ApplicationDefault) Run(ctx context.Context) (err error) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// server
+	srv := &http.Server{
+		Addr:         a.cfgAddr,
+		Handler:      a.router,
+		ReadTimeout:  a.cfgServerReadTimeout,
+		WriteTimeout: a.cfgServerWriteTimeout,
+		IdleTimeout:  a.cfgServerIdleTimeout,
+	}
+	chErr := make(chan error, 1)
+	go func() {
+		fmt.Println("run in:", a.cfgAddr)
+		chErr <- srv.ListenAndServe()
+	}()
 
-	fmt.Println("run in:", a.cfgAddr)
-	err = http.ListenAndServe(a.cfgAddr, a.router)
+	// wait
+	select {
+	case err = <-chErr:
+		return
+	case <-ctx.Done():
+	}
+
+	// shutdown
+	fmt.Println("shutting down")
+	ctxShutdown, cancel := context.WithTimeout(context.Background(), a.cfgShutdownTimeout)
+	defer cancel()
+	err = srv.Shutdown(ctxShutdown)
+	if err != nil {
+		return
+	}
+	err = <-chErr
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	return
+}
+
+// TearDown releases the resources of the application, closing the database connection.
+func (a *ApplicationDefault) TearDown() (err error) {
+	if a.db != nil {
+		err = a.db.Close()
+	}
 	return
-}
\ No newline at end of file
+}
@@ -0,0 +1,42 @@
+package application_test
+
+import (
+	"app/internal/application"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplicationDefault_Run(t *testing.T) {
+	t.Run("should stop without error when the context is done", func(t *testing.T) {
+		app := application.NewApplicationDefault(&application.ConfigApplicationDefault{
+			Addr:            "127.0.0.1:0",
+			Storage:         application.StorageMemory,
+			ShutdownTimeout: time.Second,
+		})
+		require.NoError(t, app.SetUp())
+		defer app.TearDown()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := app.Run(ctx)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should fail when the server can not listen", func(t *testing.T) {
+		app := application.NewApplicationDefault(&application.ConfigApplicationDefault{
+			Addr:    "127.0.0.1:-1",
+			Storage: application.StorageMemory,
+		})
+		require.NoError(t, app.SetUp())
+		defer app.TearDown()
+
+		err := app.Run(context.Background())
+
+		assert.Error(t, err)
+	})
+}
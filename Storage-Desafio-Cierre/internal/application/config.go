@@ -0,0 +1,303 @@
+package application
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	// ErrConfigInvalid is returned when the configuration can not be loaded or is not valid.
+	ErrConfigInvalid = errors.New("application: invalid config")
+)
+
+// setting is a configuration value that can be set from the config file,
+// the environment and the command line.
+type setting struct {
+	// key is the name of the setting in the config file.
+	// The environment variable is the key in upper case and the flag is the key with dashes.
+	key string
+	// usage is the description of the setting.
+	usage string
+	// set parses the value into the configuration.
+	set func(cfg *ConfigApplicationDefault, value string) (err error)
+}
+
+// settings are the configuration values supported by LoadConfigApplicationDefault.
+var settings = []setting{
+	{key: "db_user", usage: "database user", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.Db.User = value
+		return
+	}},
+	{key: "db_password", usage: "database password", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.Db.Passwd = value
+		return
+	}},
+	{key: "db_addr", usage: "database address as host:port", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.Db.Addr = value
+		return
+	}},
+	{key: "db_name", usage: "database name", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.Db.DBName = value
+		return
+	}},
+	{key: "db_max_open_conns", usage: "maximum number of open connections, 0 is unlimited", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.DbMaxOpenConns, err = strconv.Atoi(value)
+		return
+	}},
+	{key: "db_max_idle_conns", usage: "maximum number of idle connections", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.DbMaxIdleConns, err = strconv.Atoi(value)
+		return
+	}},
+	{key: "db_conn_max_lifetime", usage: "maximum time a connection is reused, e.g. 3m", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.DbConnMaxLifetime, err = time.ParseDuration(value)
+		return
+	}},
+	{key: "server_addr", usage: "server address", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.Addr = value
+		return
+	}},
+	{key: "server_read_timeout", usage: "maximum time to read a request, e.g. 10s", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.ServerReadTimeout, err = time.ParseDuration(value)
+		return
+	}},
+	{key: "server_write_timeout", usage: "maximum time to write a response", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.ServerWriteTimeout, err = time.ParseDuration(value)
+		return
+	}},
+	{key: "server_idle_timeout", usage: "maximum time to keep an idle connection", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.ServerIdleTimeout, err = time.ParseDuration(value)
+		return
+	}},
+	{key: "request_timeout", usage: "maximum time to handle a request before its queries are cancelled, 0 disables it", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.RequestTimeout, err = time.ParseDuration(value)
+		return
+	}},
+	{key: "shutdown_timeout", usage: "maximum time to wait for in-flight requests when stopping", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.ShutdownTimeout, err = time.ParseDuration(value)
+		return
+	}},
+	{key: "time_zone", usage: "store time zone of invoice datetimes without an offset, e.g. America/Bogota", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.TimeZone, err = time.LoadLocation(value)
+		return
+	}},
+	{key: "storage", usage: "repositories backend: mysql or memory", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.Storage = value
+		return
+	}},
+	{key: "fixtures", usage: "directory of json fixtures loaded into the memory storage", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.Fixtures = value
+		return
+	}},
+	{key: "templates", usage: "directory of html templates that override the default ones of invoice documents", set: func(cfg *ConfigApplicationDefault, value string) (err error) {
+		cfg.Templates = value
+		return
+	}},
+}
+
+// envName returns the environment variable of a setting key.
+func envName(key string) string {
+	return strings.ToUpper(key)
+}
+
+// flagName returns the command-line flag of a setting key.
+func flagName(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+// NewConfigApplicationDefault returns the default configuration used by LoadConfigApplicationDefault.
+func NewConfigApplicationDefault() *ConfigApplicationDefault {
+	db := mysql.NewConfig()
+	db.User = "root"
+	db.Passwd = "root"
+	db.Net = "tcp"
+	db.Addr = "localhost:3306"
+	db.DBName = "fantasy_products"
+	db.ParseTime = true
+
+	return &ConfigApplicationDefault{
+		Db:                 db,
+		DbMaxOpenConns:     10,
+		DbMaxIdleConns:     10,
+		DbConnMaxLifetime:  3 * time.Minute,
+		Addr:               "127.0.0.1:8080",
+		ServerReadTimeout:  10 * time.Second,
+		ServerWriteTimeout: 30 * time.Second,
+		ServerIdleTimeout:  60 * time.Second,
+		RequestTimeout:     20 * time.Second,
+		ShutdownTimeout:    15 * time.Second,
+		TimeZone:           time.UTC,
+		Storage:            StorageMySQL,
+	}
+}
+
+// LoadConfigApplicationDefault loads the configuration. Each source overrides the previous ones:
+//   - the defaults of NewConfigApplicationDefault
+//   - the json config file given by the -config flag or the CONFIG_FILE environment variable, if any
+//   - the environment variables looked up with lookupEnv, e.g. DB_USER, a variable set to an empty value included
+//   - the command-line flags of args, e.g. -db-user
+//
+// The configuration is validated before it is returned.
+func LoadConfigApplicationDefault(args []string, lookupEnv func(string) (string, bool)) (cfg *ConfigApplicationDefault, err error) {
+	cfg, err = LoadConfigApplicationDefaultWithFlags(flag.NewFlagSet("app", flag.ContinueOnError), args, lookupEnv)
+	return
+}
+
+// LoadConfigApplicationDefaultWithFlags is LoadConfigApplicationDefault with the flags of the configuration
+// added to fs, so a command can parse its own flags together with them.
+func LoadConfigApplicationDefaultWithFlags(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (cfg *ConfigApplicationDefault, err error) {
+	cfg = NewConfigApplicationDefault()
+
+	// flags
+	configFileEnv, _ := lookupEnv("CONFIG_FILE")
+	configFile := fs.String("config", configFileEnv, "json config file")
+	for _, s := range settings {
+		fs.String(flagName(s.key), "", fmt.Sprintf("%s (env %s)", s.usage, envName(s.key)))
+	}
+	err = fs.Parse(args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConfigInvalid, err)
+	}
+
+	// - file
+	if *configFile != "" {
+		err = loadConfigFile(cfg, *configFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// - env
+	for _, s := range settings {
+		value, ok := lookupEnv(envName(s.key))
+		if !ok {
+			continue
+		}
+		err = s.set(cfg, value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: env %s: %v", ErrConfigInvalid, envName(s.key), err)
+		}
+	}
+
+	// - flags
+	fs.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		for _, s := range settings {
+			if flagName(s.key) != f.Name {
+				continue
+			}
+			err = s.set(cfg, f.Value.String())
+			if err != nil {
+				err = fmt.Errorf("%w: flag -%s: %v", ErrConfigInvalid, f.Name, err)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// validate
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// loadConfigFile sets the configuration from a json object of setting keys,
+// e.g. {"db_user": "root", "db_max_open_conns": 10}.
+func loadConfigFile(cfg *ConfigApplicationDefault, path string) (err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfigInvalid, err)
+	}
+
+	var values map[string]any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	err = dec.Decode(&values)
+	if err != nil {
+		return fmt.Errorf("%w: file %s: %v", ErrConfigInvalid, path, err)
+	}
+
+	for key, value := range values {
+		var s *setting
+		for ix := range settings {
+			if settings[ix].key == key {
+				s = &settings[ix]
+				break
+			}
+		}
+		if s == nil {
+			return fmt.Errorf("%w: file %s: unknown key %q", ErrConfigInvalid, path, key)
+		}
+
+		err = s.set(cfg, fmt.Sprint(value))
+		if err != nil {
+			return fmt.Errorf("%w: file %s: key %s: %v", ErrConfigInvalid, path, key, err)
+		}
+	}
+
+	return
+}
+
+// Validate checks that the configuration can be used to set up the application.
+func (c *ConfigApplicationDefault) Validate() (err error) {
+	var msgs []string
+	if c.Addr == "" {
+		msgs = append(msgs, "server address is required")
+	}
+	switch c.Storage {
+	case StorageMySQL:
+		if c.Db == nil {
+			msgs = append(msgs, "database config is required for mysql storage")
+			break
+		}
+		if c.Db.User == "" {
+			msgs = append(msgs, "database user is required")
+		}
+		if c.Db.Addr == "" {
+			msgs = append(msgs, "database address is required")
+		}
+		if c.Db.DBName == "" {
+			msgs = append(msgs, "database name is required")
+		}
+	case StorageMemory:
+	default:
+		msgs = append(msgs, fmt.Sprintf("storage must be %s or %s, got %q", StorageMySQL, StorageMemory, c.Storage))
+	}
+	if c.DbMaxOpenConns < 0 {
+		msgs = append(msgs, "database max open conns can not be negative")
+	}
+	if c.DbMaxIdleConns < 0 {
+		msgs = append(msgs, "database max idle conns can not be negative")
+	}
+	if c.DbMaxOpenConns > 0 && c.DbMaxIdleConns > c.DbMaxOpenConns {
+		msgs = append(msgs, "database max idle conns can not be greater than max open conns")
+	}
+	if c.DbConnMaxLifetime < 0 {
+		msgs = append(msgs, "database conn max lifetime can not be negative")
+	}
+	if c.ServerReadTimeout < 0 || c.ServerWriteTimeout < 0 || c.ServerIdleTimeout < 0 || c.RequestTimeout < 0 {
+		msgs = append(msgs, "server timeouts can not be negative")
+	}
+	if c.ShutdownTimeout <= 0 {
+		msgs = append(msgs, "shutdown timeout must be positive")
+	}
+
+	if len(msgs) > 0 {
+		err = fmt.Errorf("%w: %s", ErrConfigInvalid, strings.Join(msgs, "; "))
+	}
+	return
+}
@@ -0,0 +1,116 @@
+package application_test
+
+import (
+	"app/internal/application"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lookupEnv returns a lookupEnv function backed by a map
+func lookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (value string, ok bool) {
+		value, ok = env[key]
+		return
+	}
+}
+
+func TestLoadConfigApplicationDefault(t *testing.T) {
+	t.Run("should return the defaults", func(t *testing.T) {
+		cfg, err := application.LoadConfigApplicationDefault(nil, lookupEnv(nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, "root", cfg.Db.User)
+		assert.Equal(t, "localhost:3306", cfg.Db.Addr)
+		assert.Equal(t, "fantasy_products", cfg.Db.DBName)
+		assert.Equal(t, "127.0.0.1:8080", cfg.Addr)
+		assert.Equal(t, 10, cfg.DbMaxOpenConns)
+		assert.Equal(t, 3*time.Minute, cfg.DbConnMaxLifetime)
+		assert.Equal(t, time.UTC, cfg.TimeZone)
+		assert.True(t, cfg.Db.ParseTime)
+	})
+
+	t.Run("should load the time zone", func(t *testing.T) {
+		cfg, err := application.LoadConfigApplicationDefault([]string{"-time-zone", "America/Bogota"}, lookupEnv(nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, "America/Bogota", cfg.TimeZone.String())
+	})
+
+	t.Run("should apply file, env and flags in precedence order", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		content := `{"db_user": "file", "db_name": "file_db", "db_addr": "file:3306", "db_max_open_conns": 20}`
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		env := map[string]string{
+			"CONFIG_FILE": path,
+			"DB_NAME":     "env_db",
+			"DB_ADDR":     "env:3306",
+		}
+		args := []string{"-db-addr", "flag:3306"}
+
+		cfg, err := application.LoadConfigApplicationDefault(args, lookupEnv(env))
+
+		require.NoError(t, err)
+		assert.Equal(t, "file", cfg.Db.User)
+		assert.Equal(t, 20, cfg.DbMaxOpenConns)
+		assert.Equal(t, "env_db", cfg.Db.DBName)
+		assert.Equal(t, "flag:3306", cfg.Db.Addr)
+	})
+
+	t.Run("should let an environment variable set to an empty value override the file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"db_password": "file"}`), 0o600))
+		env := map[string]string{"CONFIG_FILE": path, "DB_PASSWORD": ""}
+
+		cfg, err := application.LoadConfigApplicationDefault(nil, lookupEnv(env))
+
+		require.NoError(t, err)
+		assert.Equal(t, "", cfg.Db.Passwd)
+	})
+
+	t.Run("should parse the flags of a command together with the config ones", func(t *testing.T) {
+		fs := flag.NewFlagSet("loader", flag.ContinueOnError)
+		dir := fs.String("dir", "", "")
+		args := []string{"-dir", "fixtures", "-db-addr", "flag:3306"}
+
+		cfg, err := application.LoadConfigApplicationDefaultWithFlags(fs, args, lookupEnv(nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, "fixtures", *dir)
+		assert.Equal(t, "flag:3306", cfg.Db.Addr)
+	})
+
+	t.Run("should fail on invalid values", func(t *testing.T) {
+		env := map[string]string{"DB_MAX_OPEN_CONNS": "ten"}
+
+		_, err := application.LoadConfigApplicationDefault(nil, lookupEnv(env))
+
+		assert.ErrorIs(t, err, application.ErrConfigInvalid)
+		assert.ErrorContains(t, err, "env DB_MAX_OPEN_CONNS")
+	})
+
+	t.Run("should fail on unknown file keys", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"db_usr": "root"}`), 0o600))
+
+		_, err := application.LoadConfigApplicationDefault([]string{"-config", path}, lookupEnv(nil))
+
+		assert.ErrorIs(t, err, application.ErrConfigInvalid)
+		assert.ErrorContains(t, err, `unknown key "db_usr"`)
+	})
+
+	t.Run("should fail validation", func(t *testing.T) {
+		args := []string{"-storage", "postgres", "-db-max-idle-conns", "20"}
+
+		_, err := application.LoadConfigApplicationDefault(args, lookupEnv(nil))
+
+		assert.ErrorIs(t, err, application.ErrConfigInvalid)
+		assert.ErrorContains(t, err, `storage must be mysql or memory, got "postgres"`)
+		assert.ErrorContains(t, err, "max idle conns can not be greater than max open conns")
+	})
+}
@@ -0,0 +1,127 @@
+package internal
+
+import "sort"
+
+// BasketProduct is the number of invoices with sales of a product.
+type BasketProduct struct {
+	// ProductId is the id of the product.
+	ProductId int
+	// Description is the description of the product.
+	Description string
+	// Invoices is the number of invoices with sales of the product.
+	Invoices int
+}
+
+// BasketPair is the number of invoices with sales of both products of a pair.
+type BasketPair struct {
+	// ProductId is the id of the first product, lower than WithProductId.
+	ProductId int
+	// WithProductId is the id of the second product.
+	WithProductId int
+	// Invoices is the number of invoices with sales of both products.
+	Invoices int
+}
+
+// BasketStats are the counts of the invoices with sales the product associations are computed from.
+type BasketStats struct {
+	// Invoices is the number of invoices with sales.
+	Invoices int
+	// Products are the products with sales.
+	Products []BasketProduct
+	// Pairs are the pairs of products sold in the same invoice.
+	Pairs []BasketPair
+}
+
+// BasketQuery is the query of the product associations.
+type BasketQuery struct {
+	// ReportFilter is the filter of the invoices of the sales, its limit is the maximum number of associations.
+	ReportFilter
+	// ProductId is the product the associations are of, any if zero.
+	ProductId int
+	// MinSupport is the minimum fraction of the invoices with both products, from 0 to 1.
+	MinSupport float64
+	// MinConfidence is the minimum fraction of the invoices of the product that have the other one, from 0 to 1.
+	MinConfidence float64
+	// MinLift is the minimum ratio of the confidence to the fraction of the invoices with the other product.
+	MinLift float64
+}
+
+// Validate checks the rules of the basket query.
+func (q BasketQuery) Validate() error {
+	return JoinValidation(
+		q.ReportFilter.Validate(),
+		Validate(
+			Rule{"min_support", q.MinSupport >= 0 && q.MinSupport <= 1, "must be between 0 and 1"},
+			Rule{"min_confidence", q.MinConfidence >= 0 && q.MinConfidence <= 1, "must be between 0 and 1"},
+			Rule{"min_lift", q.MinLift >= 0, "can not be negative"},
+		),
+	)
+}
+
+// ProductAssociation is the association rule "the invoices with a product also have another one".
+type ProductAssociation struct {
+	// Product is the product of the rule.
+	Product BasketProduct
+	// With is the other product.
+	With BasketProduct
+	// Invoices is the number of invoices with both products.
+	Invoices int
+	// Support is the fraction of the invoices with both products.
+	Support float64
+	// Confidence is the fraction of the invoices of the product that have the other one.
+	Confidence float64
+	// Lift is the confidence over the fraction of the invoices with the other product,
+	// above 1 the products are bought together more than by chance.
+	Lift float64
+}
+
+// Associations returns the association rules of both directions of every pair that satisfy the thresholds of the query,
+// of its product if it is set, ordered by lift, confidence and support, up to its limit.
+func (s BasketStats) Associations(q BasketQuery) (a []ProductAssociation) {
+	if s.Invoices == 0 {
+		return
+	}
+	products := make(map[int]BasketProduct, len(s.Products))
+	for _, p := range s.Products {
+		products[p.ProductId] = p
+	}
+
+	for _, pair := range s.Pairs {
+		for _, ids := range [][2]int{{pair.ProductId, pair.WithProductId}, {pair.WithProductId, pair.ProductId}} {
+			p, with := products[ids[0]], products[ids[1]]
+			if (q.ProductId != 0 && p.ProductId != q.ProductId) || p.Invoices == 0 || with.Invoices == 0 {
+				continue
+			}
+			pa := ProductAssociation{
+				Product:    p,
+				With:       with,
+				Invoices:   pair.Invoices,
+				Support:    float64(pair.Invoices) / float64(s.Invoices),
+				Confidence: float64(pair.Invoices) / float64(p.Invoices),
+			}
+			pa.Lift = pa.Confidence / (float64(with.Invoices) / float64(s.Invoices))
+			if pa.Support < q.MinSupport || pa.Confidence < q.MinConfidence || pa.Lift < q.MinLift {
+				continue
+			}
+			a = append(a, pa)
+		}
+	}
+
+	sort.Slice(a, func(i, j int) bool {
+		switch {
+		case a[i].Lift != a[j].Lift:
+			return a[i].Lift > a[j].Lift
+		case a[i].Confidence != a[j].Confidence:
+			return a[i].Confidence > a[j].Confidence
+		case a[i].Support != a[j].Support:
+			return a[i].Support > a[j].Support
+		case a[i].Product.ProductId != a[j].Product.ProductId:
+			return a[i].Product.ProductId < a[j].Product.ProductId
+		}
+		return a[i].With.ProductId < a[j].With.ProductId
+	})
+	if q.Limit > 0 && len(a) > q.Limit {
+		a = a[:q.Limit]
+	}
+	return
+}
@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrCustomerConditionInvalid is the error returned when a customer condition can not be parsed.
+var ErrCustomerConditionInvalid = errors.New("invalid customer condition")
+
+// CustomerCondition is the lifecycle condition of a customer.
+// It is stored as the TINYINT 0 or 1 and encoded in JSON by its name.
+type CustomerCondition int
+
+const (
+	// CustomerInactive is the condition of a customer that does not buy anymore.
+	CustomerInactive CustomerCondition = 0
+	// CustomerActive is the condition of a customer that buys, the one of the top customers report.
+	CustomerActive CustomerCondition = 1
+)
+
+// customerConditionNames are the names of the conditions.
+var customerConditionNames = map[CustomerCondition]string{
+	CustomerInactive: "inactive",
+	CustomerActive:   "active",
+}
+
+// ParseCustomerCondition parses the name of a condition, e.g. active.
+func ParseCustomerCondition(s string) (c CustomerCondition, err error) {
+	for k, v := range customerConditionNames {
+		if v == s {
+			c = k
+			return
+		}
+	}
+	err = fmt.Errorf("%w: %q, must be active or inactive", ErrCustomerConditionInvalid, s)
+	return
+}
+
+// Valid reports whether the condition is one of the named ones.
+func (c CustomerCondition) Valid() bool {
+	_, ok := customerConditionNames[c]
+	return ok
+}
+
+// String returns the name of the condition, or its number if it is not valid.
+func (c CustomerCondition) String() string {
+	if name, ok := customerConditionNames[c]; ok {
+		return name
+	}
+	return strconv.Itoa(int(c))
+}
+
+// MarshalJSON encodes the condition by its name.
+func (c CustomerCondition) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+// UnmarshalJSON decodes the condition from its name or, as it used to be, from its number.
+// Numbers are not checked, so an unknown one is reported by the validation of the customer.
+func (c *CustomerCondition) UnmarshalJSON(b []byte) (err error) {
+	s := string(b)
+	if s == "null" {
+		return
+	}
+	var name string
+	if json.Unmarshal(b, &name) == nil {
+		*c, err = ParseCustomerCondition(name)
+		return
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrCustomerConditionInvalid, s)
+	}
+	*c = CustomerCondition(n)
+	return
+}
+
+// Scan implements sql.Scanner for TINYINT columns, NULL is read as inactive.
+func (c *CustomerCondition) Scan(src any) (err error) {
+	switch v := src.(type) {
+	case nil:
+		*c = CustomerInactive
+	case int64:
+		*c = CustomerCondition(v)
+	case []byte:
+		var n int
+		n, err = strconv.Atoi(string(v))
+		*c = CustomerCondition(n)
+	default:
+		err = fmt.Errorf("%w: unsupported type %T", ErrCustomerConditionInvalid, src)
+	}
+	return
+}
+
+// Value implements driver.Valuer, the condition is sent as its number.
+func (c CustomerCondition) Value() (driver.Value, error) {
+	return int64(c), nil
+}
+
+// CustomerConditionChange is the struct that represents a change of the condition of a customer,
+// an entry of its condition history.
+type CustomerConditionChange struct {
+	// Id is the unique identifier of the change.
+	Id int
+	// CustomerId is the customer id of the change.
+	CustomerId int
+	// Previous is the condition before the change.
+	Previous CustomerCondition
+	// Condition is the condition after the change.
+	Condition CustomerCondition
+	// ChangedBy is who changed the condition, the actor of the request, empty if unknown.
+	ChangedBy string
+	// Datetime is the datetime of the change.
+	Datetime time.Time
+}
+
+// ConditionAt returns the condition of a customer at t from its history ordered by datetime:
+// the condition of the last change up to t, otherwise the previous one of the first change after t.
+// A customer without changes at all has always had its current condition.
+func ConditionAt(current CustomerCondition, history []CustomerConditionChange, t time.Time) CustomerCondition {
+	c := current
+	for ix := len(history) - 1; ix >= 0; ix-- {
+		if !history[ix].Datetime.After(t) {
+			return history[ix].Condition
+		}
+		c = history[ix].Previous
+	}
+	return c
+}
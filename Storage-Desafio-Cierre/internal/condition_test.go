@@ -0,0 +1,50 @@
+package internal_test
+
+import (
+	"app/internal"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomerCondition_JSON(t *testing.T) {
+	t.Run("should encode the name and decode names and numbers", func(t *testing.T) {
+		b, err := json.Marshal(internal.CustomerActive)
+		require.NoError(t, err)
+		assert.Equal(t, `"active"`, string(b))
+
+		var c struct {
+			Name   internal.CustomerCondition `json:"name"`
+			Number internal.CustomerCondition `json:"number"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(`{"name": "inactive", "number": 1}`), &c))
+		assert.Equal(t, internal.CustomerInactive, c.Name)
+		assert.Equal(t, internal.CustomerActive, c.Number)
+	})
+
+	t.Run("should fail on an unknown name", func(t *testing.T) {
+		var c internal.CustomerCondition
+		err := json.Unmarshal([]byte(`"vip"`), &c)
+
+		assert.ErrorIs(t, err, internal.ErrCustomerConditionInvalid)
+	})
+}
+
+func TestConditionAt(t *testing.T) {
+	t.Run("should return the condition at each point of the history", func(t *testing.T) {
+		day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+		history := []internal.CustomerConditionChange{
+			{Previous: internal.CustomerActive, Condition: internal.CustomerInactive, Datetime: day(10)},
+			{Previous: internal.CustomerInactive, Condition: internal.CustomerActive, Datetime: day(20)},
+		}
+
+		assert.Equal(t, internal.CustomerActive, internal.ConditionAt(internal.CustomerActive, history, day(1)))
+		assert.Equal(t, internal.CustomerInactive, internal.ConditionAt(internal.CustomerActive, history, day(10)))
+		assert.Equal(t, internal.CustomerInactive, internal.ConditionAt(internal.CustomerActive, history, day(15)))
+		assert.Equal(t, internal.CustomerActive, internal.ConditionAt(internal.CustomerActive, history, day(25)))
+		assert.Equal(t, internal.CustomerInactive, internal.ConditionAt(internal.CustomerInactive, nil, day(25)))
+	})
+}
@@ -1,13 +1,29 @@
 package internal
 
+import (
+	"strings"
+	"unicode/utf8"
+)
+
 // CustomerAttributes is the struct that represents the attributes of a customer.
 type CustomerAttributes struct {
 	// FirstName is the first name of the customer.
 	FirstName string
 	// LastName is the last name of the customer.
 	LastName string
-	// Condition is the condition of the customer.
-	Condition int
+	// Condition is the condition of the customer, its changes are kept in its condition history.
+	Condition CustomerCondition
+}
+
+// Validate checks the rules of the customer attributes.
+func (c CustomerAttributes) Validate() error {
+	return Validate(
+		Rule{"first_name", strings.TrimSpace(c.FirstName) != "", "is required"},
+		Rule{"first_name", utf8.RuneCountInString(c.FirstName) <= 45, "must be at most 45 characters"},
+		Rule{"last_name", strings.TrimSpace(c.LastName) != "", "is required"},
+		Rule{"last_name", utf8.RuneCountInString(c.LastName) <= 45, "must be at most 45 characters"},
+		Rule{"condition", c.Condition.Valid(), "must be active or inactive"},
+	)
 }
 
 // Customer is the struct that represents a customer.
@@ -18,13 +34,31 @@ type Customer struct {
 	CustomerAttributes
 }
 
+// CustomerSortFields are the fields a list of customers can be sorted by.
+var CustomerSortFields = []string{"id", "first_name", "last_name", "condition"}
+
+// SortValue returns the value of a sort field of the customer, or nil if it is not a sort field.
+func (c Customer) SortValue(field string) (value any) {
+	switch field {
+	case "id":
+		value = c.Id
+	case "first_name":
+		value = c.FirstName
+	case "last_name":
+		value = c.LastName
+	case "condition":
+		value = c.Condition
+	}
+	return
+}
+
 type CustomerInvoicesByCondition struct {
-	Condition int
-	Total     float64
+	Condition CustomerCondition
+	Total     Money
 }
 
 type CustomerSpent struct {
 	FirstName string
-	LastName string
-	Total    float64
-}
\ No newline at end of file
+	LastName  string
+	Total     Money
+}
@@ -1,11 +1,47 @@
 package internal
 
+import (
+	"context"
+	"fmt"
+)
+
+var (
+	// ErrCustomerNotFound is returned when a customer does not exist.
+	ErrCustomerNotFound = fmt.Errorf("customer %w", ErrNotFound)
+	// ErrCustomerHasInvoices is returned when deleting a customer with invoices without cascading.
+	ErrCustomerHasInvoices = fmt.Errorf("%w: customer has invoices", ErrConflict)
+	// ErrCustomerConditionUnchanged is returned when changing the condition of a customer to the one it has.
+	ErrCustomerConditionUnchanged = fmt.Errorf("%w: customer condition unchanged", ErrConflict)
+)
+
 // RepositoryCustomer is the interface that wraps the basic methods that a customer repository should implement.
 type RepositoryCustomer interface {
-	// FindAll returns all customers saved in the database.
-	FindAll() (c []Customer, err error)
+	// FindAll returns the customers of a page
+	FindAll(ctx context.Context, page Page) (c []Customer, err error)
+	// Count returns the number of customers
+	Count(ctx context.Context) (n int, err error)
+	// FindById returns the customer with the given id.
+	FindById(ctx context.Context, id int) (c Customer, err error)
 	// Save saves a customer into the database.
-	Save(c *Customer) (err error)
-	FindTopActiveCustomersByAmountSpent(limit int) (c []CustomerSpent, err error)
-	FindInvoicesByCondition() (c []CustomerInvoicesByCondition, err error)
-}
\ No newline at end of file
+	Save(ctx context.Context, c *Customer) (err error)
+	// SaveAll saves the customers in one transaction, in order, errs has the error of each one, nil if it was saved.
+	// The transaction is only committed if commit is true and every customer was saved.
+	SaveAll(ctx context.Context, c []Customer, commit bool) (errs []error, err error)
+	// Update updates a customer in the database.
+	// A change of its condition is recorded in its condition history as made by the actor of ctx.
+	Update(ctx context.Context, c *Customer) (err error)
+	// Delete deletes a customer from the database. A customer with invoices is only deleted, cascading to its invoices
+	// and their sales, if cascade is true, otherwise it returns ErrCustomerHasInvoices so they are not removed.
+	Delete(ctx context.Context, id int, cascade bool) (err error)
+	// ChangeCondition sets the condition of a customer and records the change as made by the actor of ctx,
+	// setting its id, previous condition, actor and datetime.
+	// It returns ErrCustomerConditionUnchanged if the customer already has the condition.
+	ChangeCondition(ctx context.Context, ch *CustomerConditionChange) (err error)
+	// FindConditionHistory returns the condition changes of a customer ordered by datetime.
+	FindConditionHistory(ctx context.Context, id int) (h []CustomerConditionChange, err error)
+	// FindTopActiveCustomersByAmountSpent returns the customers of the condition of the filter, any if it has none,
+	// ordered by the total of their invoices of the period of the filter, up to its limit.
+	FindTopActiveCustomersByAmountSpent(ctx context.Context, f ReportFilter) (c []CustomerSpent, err error)
+	// FindInvoicesByCondition returns the total of the invoices of the period of the filter grouped by customer condition.
+	FindInvoicesByCondition(ctx context.Context, f ReportFilter) (c []CustomerInvoicesByCondition, err error)
+}
@@ -1,11 +1,30 @@
 package internal
 
+import "context"
+
 // ServiceCustomer is the interface that wraps the basic methods that a customer service should implement.
 type ServiceCustomer interface {
-	// FindAll returns all customers
-	FindAll() (c []Customer, err error)
+	// FindAll returns the customers of a page
+	FindAll(ctx context.Context, page Page) (c []Customer, err error)
+	// Count returns the number of customers
+	Count(ctx context.Context) (n int, err error)
+	// FindById returns a customer by id
+	FindById(ctx context.Context, id int) (c Customer, err error)
 	// Save saves a customer
-	Save(c *Customer) (err error)
-	FindTopActiveCustomersByAmountSpent(limit int) (c []CustomerSpent, err error)
-	FindInvoicesByCondition() (c []CustomerInvoicesByCondition, err error)
-}
\ No newline at end of file
+	Save(ctx context.Context, c *Customer) (err error)
+	// Import saves the customers of the rows of an import, see ImportOptions
+	Import(ctx context.Context, rows []ImportRow[Customer], o ImportOptions) (r ImportResult, err error)
+	// Update updates a customer
+	Update(ctx context.Context, c *Customer) (err error)
+	// Delete deletes a customer. Customers with invoices are only deleted,
+	// together with their invoices, if cascade is true
+	Delete(ctx context.Context, id int, cascade bool) (err error)
+	// ChangeCondition activates or deactivates a customer, recording the change in its condition history
+	ChangeCondition(ctx context.Context, ch *CustomerConditionChange) (err error)
+	// FindConditionHistory returns the condition changes of a customer ordered by datetime
+	FindConditionHistory(ctx context.Context, id int) (h []CustomerConditionChange, err error)
+	// FindTopActiveCustomersByAmountSpent returns the customers ranked by the total of their invoices, see ReportFilter
+	FindTopActiveCustomersByAmountSpent(ctx context.Context, f ReportFilter) (c []CustomerSpent, err error)
+	// FindInvoicesByCondition returns the total of the invoices grouped by customer condition, see ReportFilter
+	FindInvoicesByCondition(ctx context.Context, f ReportFilter) (c []CustomerInvoicesByCondition, err error)
+}
@@ -0,0 +1,17 @@
+package internal
+
+import "errors"
+
+// The error catalogue: every error of the domain and the repositories wraps one of these,
+// so callers can tell the kind of a failure with errors.Is without knowing the entity.
+var (
+	// ErrNotFound is returned when an entity does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when an operation conflicts with the current data,
+	// e.g. a duplicate id or deleting an entity that is still referenced.
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidReference is returned when an entity references another one that does not exist.
+	ErrInvalidReference = errors.New("invalid reference")
+	// ErrValidation is returned when an entity does not satisfy its rules, see ValidationError.
+	ErrValidation = errors.New("validation failed")
+)
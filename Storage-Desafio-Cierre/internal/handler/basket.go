@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"app/internal"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+)
+
+// ProductAssociationJSON is a struct that represents an association of products in JSON format
+type ProductAssociationJSON struct {
+	ProductId       int     `json:"product_id"`
+	Description     string  `json:"description"`
+	WithProductId   int     `json:"with_product_id"`
+	WithDescription string  `json:"with_description"`
+	Invoices        int     `json:"invoices"`
+	Support         float64 `json:"support"`
+	Confidence      float64 `json:"confidence"`
+	Lift            float64 `json:"lift"`
+}
+
+// GetAssociations returns the pairs of products bought in the same invoices with their support, confidence and lift,
+// see basketQueryFromRequest
+func (h *ProductsDefault) GetAssociations() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		q, err := basketQueryFromRequest(r, h.loc)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// process
+		h.associations(w, r, q)
+	}
+}
+
+// GetBoughtWith returns the products frequently bought with a product, see basketQueryFromRequest
+func (h *ProductsDefault) GetBoughtWith() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		q, err := basketQueryFromRequest(r, h.loc)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		q.ProductId = id
+
+		// process
+		h.associations(w, r, q)
+	}
+}
+
+// associations finds the associations of the query and writes the response
+func (h *ProductsDefault) associations(w http.ResponseWriter, r *http.Request, q internal.BasketQuery) {
+	// - find
+	a, err := h.sv.FindAssociations(r.Context(), q)
+	if err != nil {
+		responseError(w, err, "error getting associations")
+		return
+	}
+
+	// response
+	// - serialize
+	aJSON := make([]ProductAssociationJSON, len(a))
+	for ix, v := range a {
+		aJSON[ix] = ProductAssociationJSON{
+			ProductId:       v.Product.ProductId,
+			Description:     v.Product.Description,
+			WithProductId:   v.With.ProductId,
+			WithDescription: v.With.Description,
+			Invoices:        v.Invoices,
+			Support:         round(v.Support, 4),
+			Confidence:      round(v.Confidence, 4),
+			Lift:            round(v.Lift, 4),
+		}
+	}
+	responseList(w, r, map[string]any{
+		"message": "associations found",
+		"data":    aJSON,
+	})
+}
+
+// basketQueryFromRequest returns the basket query of the query params of ReportFilterFromRequest and:
+//   - min_support: minimum fraction of the invoices with both products, from 0 to 1, 0 if missing
+//   - min_confidence: minimum fraction of the invoices of the product with the other one, from 0 to 1, 0 if missing
+//   - min_lift: minimum lift, 0 if missing
+func basketQueryFromRequest(r *http.Request, loc *time.Location) (q internal.BasketQuery, err error) {
+	q.ReportFilter, err = ReportFilterFromRequest(r, loc)
+	if err != nil {
+		return
+	}
+
+	for _, v := range []struct {
+		name  string
+		value *float64
+	}{
+		{"min_support", &q.MinSupport},
+		{"min_confidence", &q.MinConfidence},
+		{"min_lift", &q.MinLift},
+	} {
+		s := r.URL.Query().Get(v.name)
+		if s == "" {
+			continue
+		}
+		*v.value, err = strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(*v.value) {
+			err = fmt.Errorf("invalid %s %q", v.name, s)
+			return
+		}
+	}
+	return
+}
+
+// round returns x rounded to a number of decimals
+func round(x float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(x*p) / p
+}
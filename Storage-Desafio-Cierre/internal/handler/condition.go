@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"app/internal"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+)
+
+// ConditionChangeJSON is a struct that represents a change of the condition of a customer in JSON format
+type ConditionChangeJSON struct {
+	Id         int                        `json:"id"`
+	CustomerId int                        `json:"customer_id"`
+	Previous   internal.CustomerCondition `json:"previous"`
+	Condition  internal.CustomerCondition `json:"condition"`
+	ChangedBy  string                     `json:"changed_by"`
+	Datetime   string                     `json:"datetime"`
+}
+
+// Activate sets the condition of a customer to active
+func (h *CustomersDefault) Activate() http.HandlerFunc {
+	return h.changeCondition(internal.CustomerActive, "customer activated")
+}
+
+// Deactivate sets the condition of a customer to inactive
+func (h *CustomersDefault) Deactivate() http.HandlerFunc {
+	return h.changeCondition(internal.CustomerInactive, "customer deactivated")
+}
+
+// changeCondition returns the handler that sets the condition of a customer, recording the change
+// as made by the actor of the request, see Actor
+func (h *CustomersDefault) changeCondition(condition internal.CustomerCondition, message string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		ch := internal.CustomerConditionChange{CustomerId: id, Condition: condition}
+		err = h.sv.ChangeCondition(r.Context(), &ch)
+		if err != nil {
+			responseError(w, err, "error changing customer condition")
+			return
+		}
+
+		// response
+		// - serialize
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": message,
+			"data":    h.conditionChangeJSON(ch),
+		})
+	}
+}
+
+// GetConditionHistory returns the condition changes of a customer ordered by datetime
+func (h *CustomersDefault) GetConditionHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		history, err := h.sv.FindConditionHistory(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error getting customer condition history")
+			return
+		}
+
+		// response
+		// - serialize
+		hJSON := make([]ConditionChangeJSON, len(history))
+		for ix, v := range history {
+			hJSON[ix] = h.conditionChangeJSON(v)
+		}
+		responseList(w, r, map[string]any{
+			"message": "customer condition history found",
+			"data":    hJSON,
+		})
+	}
+}
+
+// conditionChangeJSON returns the change in JSON format, with its datetime in the store time zone
+func (h *CustomersDefault) conditionChangeJSON(ch internal.CustomerConditionChange) ConditionChangeJSON {
+	return ConditionChangeJSON{
+		Id:         ch.Id,
+		CustomerId: ch.CustomerId,
+		Previous:   ch.Previous,
+		Condition:  ch.Condition,
+		ChangedBy:  ch.ChangedBy,
+		Datetime:   formatDatetime(ch.Datetime, h.loc),
+	}
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bootcamp-go/web/response"
+)
+
+const (
+	// FormatJSON is the format of the responses by default.
+	FormatJSON = "json"
+	// FormatCSV is the format of the list and report responses for spreadsheets.
+	FormatCSV = "csv"
+)
+
+// csvFormulaPrefixes are the first characters of a cell that spreadsheets read as a formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// FormatFromRequest returns the format a list or report request asks for:
+//   - format: json or csv, it takes precedence over the Accept header
+//   - Accept: the one of application/json or text/csv with the highest q-value, the first one on a tie.
+//     A q-value of 0 marks a media type as not acceptable.
+//
+// It is FormatJSON if the request asks for neither.
+func FormatFromRequest(r *http.Request) (format string, err error) {
+	format = FormatJSON
+	if v := r.URL.Query().Get("format"); v != "" {
+		if v != FormatJSON && v != FormatCSV {
+			err = fmt.Errorf("invalid format %q, must be json or csv", v)
+		}
+		format = v
+		return
+	}
+
+	var best float64
+	for _, v := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(v))
+		if err != nil {
+			continue
+		}
+		var f string
+		switch mediaType {
+		case "application/json":
+			f = FormatJSON
+		case "text/csv":
+			f = FormatCSV
+		default:
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if q > best {
+			format, best = f, q
+		}
+	}
+	return
+}
+
+// responseList writes the response of a list or report in the format of the request, see FormatFromRequest.
+// The CSV has the rows of the data of the body, the paging of lists is in the X-Total-Count
+// and X-Next-Cursor headers.
+func responseList(w http.ResponseWriter, r *http.Request, body map[string]any) {
+	format, err := FormatFromRequest(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if format != FormatCSV {
+		response.JSON(w, http.StatusOK, body)
+		return
+	}
+
+	if pg, ok := body["paging"].(PagingJSON); ok {
+		w.Header().Set("X-Total-Count", strconv.Itoa(pg.Total))
+		if pg.NextCursor != "" {
+			w.Header().Set("X-Next-Cursor", pg.NextCursor)
+		}
+	}
+	responseCSV(w, r, body["data"])
+}
+
+// responseCSV writes a slice of structs as CSV, one row per item flushed to the client as it is encoded,
+// if the writer is an http.Flusher.
+// The header has the JSON names of the fields and each value is written as in the JSON response,
+// without quotes for strings and empty for null, see csvValue.
+func responseCSV(w http.ResponseWriter, r *http.Request, rows any) {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Struct {
+		response.Error(w, http.StatusNotAcceptable, "csv is not available for this response")
+		return
+	}
+
+	// header: the fields with a JSON name
+	t := v.Type().Elem()
+	var fields []int
+	var header []string
+	for ix := 0; ix < t.NumField(); ix++ {
+		name, _, _ := strings.Cut(t.Field(ix).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, ix)
+		header = append(header, name)
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(r.URL.Path)+".csv"))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	flusher, _ := w.(http.Flusher)
+	if !writeCSVRow(cw, flusher, header) {
+		return
+	}
+	record := make([]string, len(fields))
+	for ix := 0; ix < v.Len(); ix++ {
+		for jx, field := range fields {
+			record[jx] = csvValue(v.Index(ix).Field(field).Interface())
+		}
+		if !writeCSVRow(cw, flusher, record) {
+			return
+		}
+	}
+}
+
+// writeCSVRow writes a row and flushes it to the client, it returns false if the client is gone
+func writeCSVRow(cw *csv.Writer, flusher http.Flusher, record []string) (ok bool) {
+	_ = cw.Write(record)
+	cw.Flush()
+	if cw.Error() != nil {
+		return
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+	ok = true
+	return
+}
+
+// csvValue returns a value as it is in the JSON response, strings without quotes and null as empty.
+// Strings that a spreadsheet would read as a formula are escaped with a leading ', see csvEscape.
+func csvValue(value any) string {
+	b, err := json.Marshal(value)
+	if err != nil || string(b) == "null" {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		return csvEscape(s)
+	}
+	return string(b)
+}
+
+// csvEscape prefixes with ' a string starting with a formula character, so a spreadsheet shows it as text
+func csvEscape(s string) string {
+	if s != "" && strings.ContainsRune(csvFormulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// csvUnescape removes the ' that csvEscape prefixes to a string starting with a formula character
+func csvUnescape(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
@@ -1,45 +1,66 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"app/internal"
 
 	"github.com/bootcamp-go/web/request"
 	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
 )
 
 // NewCustomersDefault returns a new CustomersDefault
-func NewCustomersDefault(sv internal.ServiceCustomer) *CustomersDefault {
-	return &CustomersDefault{sv: sv}
+func NewCustomersDefault(sv internal.ServiceCustomer, loc *time.Location) *CustomersDefault {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &CustomersDefault{sv: sv, loc: loc}
 }
 
 // CustomersDefault is a struct that returns the customer handlers
 type CustomersDefault struct {
 	// sv is the customer's service
 	sv internal.ServiceCustomer
+	// loc is the store time zone, datetimes without an offset are read in it
+	loc *time.Location
 }
 
 // CustomerJSON is a struct that represents a customer in JSON format
 type CustomerJSON struct {
-	Id        int    `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Condition int    `json:"condition"`
+	Id        int                        `json:"id"`
+	FirstName string                     `json:"first_name"`
+	LastName  string                     `json:"last_name"`
+	Condition internal.CustomerCondition `json:"condition"`
 }
 
 // GetAll returns all customers
 func (h *CustomersDefault) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - page
+		page, err := PageFromRequest(r, internal.CustomerSortFields)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
 
 		// process
-		c, err := h.sv.FindAll()
+		c, err := h.sv.FindAll(r.Context(), page)
+		if err != nil {
+			log.Println(err)
+			responseError(w, err, "error getting customers")
+			return
+		}
+		total, err := h.sv.Count(r.Context())
 		if err != nil {
 			log.Println(err)
-			response.Error(w, http.StatusInternalServerError, "error getting customers")
+			responseError(w, err, "error getting customers")
 			return
 		}
 
@@ -54,25 +75,37 @@ func (h *CustomersDefault) GetAll() http.HandlerFunc {
 				Condition: v.Condition,
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
+		responseList(w, r, map[string]any{
 			"message": "customers found",
 			"data":    csJSON,
+			"paging":  NewPagingJSON(page, total, nextCursor(page, c)),
 		})
 	}
 }
 
 type CustomerSpentResponseDto struct {
-	FirstName string  `json:"first_name"`
-	LastName  string  `json:"last_name"`
-	Total     float64 `json:"total"`
+	FirstName string         `json:"first_name"`
+	LastName  string         `json:"last_name"`
+	Total     internal.Money `json:"total"`
 }
 
+// GetTopActiveCustomersByAmountSpent returns the customers ranked by the total of their invoices,
+// filtered by the query params of ReportFilterFromRequest, the active ones if condition is missing
 func (h *CustomersDefault) GetTopActiveCustomersByAmountSpent() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		f, err := ReportFilterFromRequest(r, h.loc)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if f.Condition == nil {
+			active := internal.CustomerActive
+			f.Condition = &active
+		}
 
-		customersSpent, err := h.sv.FindTopActiveCustomersByAmountSpent(5)
+		customersSpent, err := h.sv.FindTopActiveCustomersByAmountSpent(r.Context(), f)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error getting customers")
+			responseError(w, err, "error getting customers")
 			return
 		}
 
@@ -84,7 +117,7 @@ func (h *CustomersDefault) GetTopActiveCustomersByAmountSpent() http.HandlerFunc
 				Total:     v.Total,
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
+		responseList(w, r, map[string]any{
 			"message": "customers found",
 			"data":    CustomerSpentResponse,
 		})
@@ -92,15 +125,23 @@ func (h *CustomersDefault) GetTopActiveCustomersByAmountSpent() http.HandlerFunc
 }
 
 type CustomerInvoicesByConditionResponseDto struct {
-	Condition int `json:"condition"`
-	Total     float64 `json:"total"`
+	Condition internal.CustomerCondition `json:"condition"`
+	Total     internal.Money             `json:"total"`
 }
 
+// GetInvoicesByCondition returns the total of the invoices grouped by customer condition,
+// filtered by the query params of ReportFilterFromRequest except limit
 func (h *CustomersDefault) GetInvoicesByCondition() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		customersCondition, err := h.sv.FindInvoicesByCondition()
+		f, err := ReportFilterFromRequest(r, h.loc)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		customersCondition, err := h.sv.FindInvoicesByCondition(r.Context(), f)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error get customers")
+			responseError(w, err, "error get customers")
 			return
 		}
 
@@ -111,7 +152,7 @@ func (h *CustomersDefault) GetInvoicesByCondition() http.HandlerFunc {
 				Total:     v.Total,
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
+		responseList(w, r, map[string]any{
 			"message": "customers found",
 			"data":    csJSON,
 		})
@@ -119,9 +160,9 @@ func (h *CustomersDefault) GetInvoicesByCondition() http.HandlerFunc {
 }
 
 type RequestBodyCreateCustomerDto struct {
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Condition int    `json:"condition"`
+	FirstName string                     `json:"first_name"`
+	LastName  string                     `json:"last_name"`
+	Condition internal.CustomerCondition `json:"condition"`
 }
 
 // Create creates a new customer
@@ -146,9 +187,9 @@ func (h *CustomersDefault) Create() http.HandlerFunc {
 			},
 		}
 		// - save
-		err = h.sv.Save(&c)
+		err = h.sv.Save(r.Context(), &c)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error saving customer")
+			responseError(w, err, "error saving customer")
 			return
 		}
 
@@ -166,3 +207,202 @@ func (h *CustomersDefault) Create() http.HandlerFunc {
 		})
 	}
 }
+
+// Import creates the customers of a CSV or a JSON array of CustomerJSON, the id is optional,
+// see importRowsFromRequest and ImportOptionsFromRequest
+func (h *CustomersDefault) Import() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		o, err := ImportOptionsFromRequest(r)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// - body
+		rowsJSON, err := importRowsFromRequest[CustomerJSON](w, r)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// process
+		// - deserialize
+		rows := make([]internal.ImportRow[internal.Customer], len(rowsJSON))
+		for ix, v := range rowsJSON {
+			rows[ix] = internal.ImportRow[internal.Customer]{
+				Row: v.Row,
+				Entity: internal.Customer{
+					Id: v.Entity.Id,
+					CustomerAttributes: internal.CustomerAttributes{
+						FirstName: v.Entity.FirstName,
+						LastName:  v.Entity.LastName,
+						Condition: v.Entity.Condition,
+					},
+				},
+				Err: v.Err,
+			}
+		}
+		// - import
+		res, err := h.sv.Import(r.Context(), rows, o)
+		if err != nil {
+			responseError(w, err, "error importing customers")
+			return
+		}
+
+		// response
+		responseImport(w, o, res, "customers")
+	}
+}
+
+// GetById returns a customer by id
+func (h *CustomersDefault) GetById() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		c, err := h.sv.FindById(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error getting customer")
+			return
+		}
+
+		// response
+		// - serialize
+		cs := CustomerJSON{
+			Id:        c.Id,
+			FirstName: c.FirstName,
+			LastName:  c.LastName,
+			Condition: c.Condition,
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "customer found",
+			"data":    cs,
+		})
+	}
+}
+
+// Update replaces a customer
+func (h *CustomersDefault) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		// - body
+		var reqBody RequestBodyCreateCustomerDto
+		err = request.JSON(r, &reqBody)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "error deserializing request body")
+			return
+		}
+
+		// process
+		h.update(w, r, id, reqBody)
+	}
+}
+
+// Patch partially updates a customer, keeping the fields missing in the body
+func (h *CustomersDefault) Patch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		// - current customer
+		c, err := h.sv.FindById(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error getting customer")
+			return
+		}
+		// - body: decoded over the current values
+		reqBody := RequestBodyCreateCustomerDto{
+			FirstName: c.FirstName,
+			LastName:  c.LastName,
+			Condition: c.Condition,
+		}
+		err = request.JSON(r, &reqBody)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "error deserializing request body")
+			return
+		}
+
+		// process
+		h.update(w, r, id, reqBody)
+	}
+}
+
+// update saves the customer of the request body and writes the response
+func (h *CustomersDefault) update(w http.ResponseWriter, r *http.Request, id int, reqBody RequestBodyCreateCustomerDto) {
+	// - deserialize
+	c := internal.Customer{
+		Id: id,
+		CustomerAttributes: internal.CustomerAttributes{
+			FirstName: reqBody.FirstName,
+			LastName:  reqBody.LastName,
+			Condition: reqBody.Condition,
+		},
+	}
+	// - update
+	err := h.sv.Update(r.Context(), &c)
+	if err != nil {
+		responseError(w, err, "error updating customer")
+		return
+	}
+
+	// response
+	// - serialize
+	cs := CustomerJSON{
+		Id:        c.Id,
+		FirstName: c.FirstName,
+		LastName:  c.LastName,
+		Condition: c.Condition,
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "customer updated",
+		"data":    cs,
+	})
+}
+
+// Delete deletes a customer. Customers with invoices are only deleted,
+// together with their invoices and sales, with the query param cascade=true
+func (h *CustomersDefault) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		// - query
+		var cascade bool
+		if v := r.URL.Query().Get("cascade"); v != "" {
+			cascade, err = strconv.ParseBool(v)
+			if err != nil {
+				response.Error(w, http.StatusBadRequest, "invalid cascade")
+				return
+			}
+		}
+
+		// process
+		err = h.sv.Delete(r.Context(), id, cascade)
+		if err != nil {
+			if errors.Is(err, internal.ErrCustomerHasInvoices) {
+				err = fmt.Errorf("%w, use cascade=true to delete them too", err)
+			}
+			responseError(w, err, "error deleting customer")
+			return
+		}
+
+		// response
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
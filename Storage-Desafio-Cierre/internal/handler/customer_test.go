@@ -1,17 +1,26 @@
 package handler_test
 
 import (
+	"app/internal"
 	"app/internal/handler"
 	"app/internal/repository"
+	"app/internal/repository/memory"
 	"app/internal/service"
+	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-txdb"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func init() {
@@ -94,7 +103,7 @@ func TestGetTopActiveCustomers(t *testing.T) {
 		// inject dependency
 		repo := repository.NewCustomersMySQL(db)
 		service := service.NewCustomersDefault(repo)
-		handler := handler.NewCustomersDefault(service)
+		handler := handler.NewCustomersDefault(service, time.UTC)
 
 		handlerFunc := handler.GetTopActiveCustomersByAmountSpent()
 		request := httptest.NewRequest(http.MethodGet, "/customers/top-active-customers-by-amount-spent", nil)
@@ -155,7 +164,7 @@ func TestGetTopActiveCustomers(t *testing.T) {
 		// inject
 		repo := repository.NewCustomersMySQL(db)
 		service := service.NewCustomersDefault(repo)
-		handler := handler.NewCustomersDefault(service)
+		handler := handler.NewCustomersDefault(service, time.UTC)
 
 		handlerFunc := handler.GetTopActiveCustomersByAmountSpent()
 		request := httptest.NewRequest(http.MethodGet, "/customers/top-active-customers-by-amount-spent", nil)
@@ -185,7 +194,7 @@ func TestGetInvoicesByCondition(t *testing.T) {
 
 		repo := repository.NewCustomersMySQL(db)
 		service := service.NewCustomersDefault(repo)
-		handler := handler.NewCustomersDefault(service)
+		handler := handler.NewCustomersDefault(service, time.UTC)
 
 		handlerFunc := handler.GetInvoicesByCondition()
 		request := httptest.NewRequest(http.MethodGet, "/customers/invoices-by-condition", nil)
@@ -199,11 +208,11 @@ func TestGetInvoicesByCondition(t *testing.T) {
 				"message": "customers found",
 				"data": [
 					{
-						"condition": 1,
+						"condition": "active",
 						"total": 2300
 					},
 					{
-						"condition": 0,
+						"condition": "inactive",
 						"total": 23
 					}
 				]
@@ -225,7 +234,7 @@ func TestGetInvoicesByCondition(t *testing.T) {
 
 		repo := repository.NewCustomersMySQL(db)
 		service := service.NewCustomersDefault(repo)
-		handler := handler.NewCustomersDefault(service)
+		handler := handler.NewCustomersDefault(service, time.UTC)
 
 		handlerFunc := handler.GetInvoicesByCondition()
 		request := httptest.NewRequest(http.MethodGet, "/customers/invoices-by-condition", nil)
@@ -242,3 +251,378 @@ func TestGetInvoicesByCondition(t *testing.T) {
 		assert.NoError(t, ResetDB(db))
 	})
 }
+
+// NewCustomersRouter returns the router of NewRouter with an active customer with an invoice and an inactive one
+func NewCustomersRouter(t *testing.T) (rt *chi.Mux, st *memory.Store) {
+	st = memory.NewStore()
+	rpCustomer := memory.NewCustomersMemory(st)
+	rpInvoice := memory.NewInvoicesMemory(st)
+	for _, c := range []internal.Customer{
+		{CustomerAttributes: internal.CustomerAttributes{FirstName: "Michael", LastName: "Jordan", Condition: 1}},
+		{CustomerAttributes: internal.CustomerAttributes{FirstName: "Ada", LastName: "Lovelace", Condition: 0}},
+	} {
+		require.NoError(t, rpCustomer.Save(context.Background(), &c))
+	}
+	i := internal.Invoice{InvoiceAttributes: internal.InvoiceAttributes{CustomerId: 1, Total: 10000}}
+	require.NoError(t, rpInvoice.Save(context.Background(), &i))
+
+	rt = NewRouter(st, time.UTC)
+	return
+}
+
+func TestCustomersDefault_GetAll(t *testing.T) {
+	t.Run("should return the first page with a cursor to the next one", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/customers?limit=1&sort=-first_name", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		var body struct {
+			Data   []handler.CustomerJSON `json:"data"`
+			Paging handler.PagingJSON     `json:"paging"`
+		}
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "Michael", body.Data[0].FirstName)
+		assert.Equal(t, 2, body.Paging.Total)
+		assert.Equal(t, "-first_name", body.Paging.Sort)
+		assert.NotEmpty(t, body.Paging.NextCursor)
+
+		// next page
+		request = httptest.NewRequest(http.MethodGet, "/customers?limit=1&sort=-first_name&cursor="+body.Paging.NextCursor, nil)
+		response = httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{
+			"message": "customers found",
+			"data": [{"id": 2, "first_name": "Ada", "last_name": "Lovelace", "condition": "inactive"}],
+			"paging": {"limit": 1, "offset": 0, "sort": "-first_name", "total": 2, "next_cursor": "eyJzIjoiLWZpcnN0X25hbWUiLCJ2IjoiQWRhIiwiaWQiOjJ9"}
+		}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should page by condition in the stored order, inactive first", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/customers?limit=1&sort=condition", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		var body struct {
+			Data   []handler.CustomerJSON `json:"data"`
+			Paging handler.PagingJSON     `json:"paging"`
+		}
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "Ada", body.Data[0].FirstName)
+		cursor, err := base64.RawURLEncoding.DecodeString(body.Paging.NextCursor)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"s": "condition", "v": 0, "id": 2}`, string(cursor))
+
+		// next page
+		request = httptest.NewRequest(http.MethodGet, "/customers?limit=1&sort=condition&cursor="+body.Paging.NextCursor, nil)
+		response = httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Body.String(), `"first_name":"Michael"`)
+	})
+
+	t.Run("should return csv with the json names as header", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/customers?limit=1", nil)
+		request.Header.Set("Accept", "text/csv")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := "id,first_name,last_name,condition\n1,Michael,Jordan,active\n"
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", response.Header().Get("Content-Type"))
+		assert.Equal(t, "2", response.Header().Get("X-Total-Count"))
+		assert.Equal(t, expectedResponse, response.Body.String())
+		assert.True(t, response.Flushed)
+	})
+
+	t.Run("should answer the accepted format with the highest q-value", func(t *testing.T) {
+		for accept, contentType := range map[string]string{
+			"text/csv;q=0":                         "application/json",
+			"text/csv;q=0, application/json;q=0.1": "application/json",
+			"application/json;q=0.5, text/csv":     "text/csv; charset=utf-8",
+		} {
+			rt, _ := NewCustomersRouter(t)
+			request := httptest.NewRequest(http.MethodGet, "/customers", nil)
+			request.Header.Set("Accept", accept)
+			response := httptest.NewRecorder()
+
+			rt.ServeHTTP(response, request)
+
+			assert.Equal(t, http.StatusOK, response.Code, accept)
+			assert.Equal(t, contentType, response.Header().Get("Content-Type"), accept)
+		}
+	})
+
+	t.Run("should escape the values a spreadsheet reads as formulas", func(t *testing.T) {
+		rt, st := NewCustomersRouter(t)
+		c := internal.Customer{CustomerAttributes: internal.CustomerAttributes{FirstName: "=HYPERLINK(\"http://x\")", LastName: "-2+3", Condition: 1}}
+		require.NoError(t, memory.NewCustomersMemory(st).Save(context.Background(), &c))
+		request := httptest.NewRequest(http.MethodGet, "/customers?offset=2&format=csv", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := "id,first_name,last_name,condition\n3,\"'=HYPERLINK(\"\"http://x\"\")\",'-2+3,active\n"
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should reject an unknown format", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/customers?format=xml", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("should reject an unknown sort field", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/customers?sort=age", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+func TestCustomersDefault_GetById(t *testing.T) {
+	t.Run("should return the customer", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/customers/1", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "customer found", "data": {"id": 1, "first_name": "Michael", "last_name": "Jordan", "condition": "active"}}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should return not found", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/customers/99", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+}
+
+func TestCustomersDefault_Create(t *testing.T) {
+	t.Run("should return the field errors", func(t *testing.T) {
+		rt, st := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodPost, "/customers", strings.NewReader(`{"first_name": " ", "condition": 3}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{
+			"status": "Unprocessable Entity",
+			"message": "validation failed",
+			"errors": [
+				{"field": "first_name", "message": "is required"},
+				{"field": "last_name", "message": "is required"},
+				{"field": "condition", "message": "must be active or inactive"}
+			]
+		}`
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+		n, err := memory.NewCustomersMemory(st).Count(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+	})
+}
+
+func TestCustomersDefault_Patch(t *testing.T) {
+	t.Run("should keep the fields missing in the body", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodPatch, "/customers/2", strings.NewReader(`{"condition": 1}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "customer updated", "data": {"id": 2, "first_name": "Ada", "last_name": "Lovelace", "condition": "active"}}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+}
+
+func TestCustomersDefault_Update(t *testing.T) {
+	t.Run("should return not found", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodPut, "/customers/99", strings.NewReader(`{"first_name": "Alan", "last_name": "Turing", "condition": 0}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+}
+
+func TestCustomersDefault_Delete(t *testing.T) {
+	t.Run("should refuse to delete a customer with invoices", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodDelete, "/customers/1", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusConflict, response.Code)
+	})
+
+	t.Run("should delete the customer and its invoices with cascade", func(t *testing.T) {
+		rt, st := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodDelete, "/customers/1?cascade=true", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		invoices, err := memory.NewInvoicesMemory(st).FindAll(context.Background(), internal.Page{})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, response.Code)
+		assert.Empty(t, response.Body.String())
+		assert.Empty(t, invoices)
+	})
+}
+
+func TestCustomersDefault_Deactivate(t *testing.T) {
+	t.Run("should deactivate the customer and record who did it", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodPost, "/customers/1/deactivate", nil)
+		request.Header.Set(handler.ActorHeader, "ana")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		var body struct {
+			Data handler.ConditionChangeJSON `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&body))
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, internal.CustomerActive, body.Data.Previous)
+		assert.Equal(t, internal.CustomerInactive, body.Data.Condition)
+		assert.Equal(t, "ana", body.Data.ChangedBy)
+
+		// history
+		request = httptest.NewRequest(http.MethodGet, "/customers/1/condition-history", nil)
+		response = httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		var history struct {
+			Data []handler.ConditionChangeJSON `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&history))
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, []handler.ConditionChangeJSON{body.Data}, history.Data)
+	})
+
+	t.Run("should refuse to deactivate an inactive customer", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodPost, "/customers/2/deactivate", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"status": "Conflict", "message": "conflict: customer condition unchanged: customer 2 is already inactive"}`
+		assert.Equal(t, http.StatusConflict, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+}
+
+func TestCustomersDefault_GetTopActiveCustomersByAmountSpent(t *testing.T) {
+	t.Run("should take the condition at the given datetime", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodPost, "/customers/1/deactivate", nil)
+		response := httptest.NewRecorder()
+		rt.ServeHTTP(response, request)
+		require.Equal(t, http.StatusOK, response.Code)
+
+		// before the change
+		request = httptest.NewRequest(http.MethodGet, "/customers/top-active?at=2020-01-01", nil)
+		response = httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "customers found", "data": [{"first_name": "Michael", "last_name": "Jordan", "total": 100}]}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+
+		// now
+		request = httptest.NewRequest(http.MethodGet, "/customers/top-active", nil)
+		response = httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse = `{"message": "customers found", "data": []}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+}
+
+func TestCustomersDefault_Import(t *testing.T) {
+	t.Run("should save the rows that do not fail in a partial import", func(t *testing.T) {
+		rt, st := NewCustomersRouter(t)
+		body := "id,first_name,last_name,condition\n,Grace,Hopper,active\n1,Michael,Jordan,active\n,Alan,Turing,vip\n"
+		request := httptest.NewRequest(http.MethodPost, "/customers/import?mode=partial", strings.NewReader(body))
+		request.Header.Set("Content-Type", "text/csv; charset=utf-8")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Body.String(), `"inserted":1,"skipped":0,"failed":2`)
+		assert.Contains(t, response.Body.String(), `{"row":2,"error":"conflict: memory: duplicate id"}`)
+		assert.Contains(t, response.Body.String(), `"row":3`)
+		c, err := memory.NewCustomersMemory(st).FindById(context.Background(), 3)
+		assert.NoError(t, err)
+		assert.Equal(t, "Grace", c.FirstName)
+	})
+
+	t.Run("should unescape the values escaped as formulas by the export", func(t *testing.T) {
+		rt, st := NewCustomersRouter(t)
+		body := "first_name,last_name,condition\n'=Grace,'Hopper,active\n"
+		request := httptest.NewRequest(http.MethodPost, "/customers/import", strings.NewReader(body))
+		request.Header.Set("Content-Type", "text/csv")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		c, err := memory.NewCustomersMemory(st).FindById(context.Background(), 3)
+		assert.NoError(t, err)
+		assert.Equal(t, "=Grace", c.FirstName)
+		assert.Equal(t, "'Hopper", c.LastName)
+	})
+
+	t.Run("should reject an unknown column", func(t *testing.T) {
+		rt, _ := NewCustomersRouter(t)
+		request := httptest.NewRequest(http.MethodPost, "/customers/import", strings.NewReader("first_name,age\nGrace,85\n"))
+		request.Header.Set("Content-Type", "text/csv")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+)
+
+// DocumentTemplate is the name of the template of the invoice documents
+const DocumentTemplate = "invoice.html"
+
+// templatesDefault are the templates of the documents embedded in the binary
+//
+//go:embed templates/*.html
+var templatesDefault embed.FS
+
+// templatesDocument are the default templates of the documents
+var templatesDocument = template.Must(template.ParseFS(templatesDefault, "templates/*.html"))
+
+// ParseTemplates returns the default templates of the documents overridden by the *.html files of a directory,
+// or the default ones if dir is empty. A file named as a default template replaces it and a file that defines
+// a block of it, e.g. {{define "header"}}, replaces only the block.
+func ParseTemplates(dir string) (t *template.Template, err error) {
+	t, err = template.ParseFS(templatesDefault, "templates/*.html")
+	if err != nil || dir == "" {
+		return
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return
+	}
+	if len(files) == 0 {
+		err = fmt.Errorf("templates: no *.html files in %s", dir)
+		return
+	}
+	t, err = t.ParseFiles(files...)
+	return
+}
+
+// SetTemplates sets the templates the documents are rendered with, see ParseTemplates
+func (h *InvoicesDefault) SetTemplates(t *template.Template) {
+	h.tpl = t
+}
+
+// GetDocument returns the invoice with its customer and lines as a printable HTML document
+func (h *InvoicesDefault) GetDocument() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		d, err := h.sv.FindDocument(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error getting invoice document")
+			return
+		}
+		d.Datetime = d.Datetime.In(h.loc)
+
+		// - render: into a buffer so a template error is still a JSON error
+		var b bytes.Buffer
+		err = h.tpl.ExecuteTemplate(&b, DocumentTemplate, d)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "error rendering invoice document")
+			return
+		}
+
+		// response
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = b.WriteTo(w)
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"app/internal"
+
+	"github.com/bootcamp-go/web/response"
+)
+
+// responseError writes the response of an error of the service by its kind in the error catalogue:
+//   - internal.ErrNotFound: 404 Not Found
+//   - internal.ErrConflict: 409 Conflict
+//   - internal.ErrInvalidReference: 422 Unprocessable Entity
+//   - internal.ErrValidation: 422 Unprocessable Entity with the field errors
+//   - internal.ErrPageInvalid, internal.ErrDatetimeInvalid, internal.ErrMoneyInvalid
+//     and internal.ErrCustomerConditionInvalid: 400 Bad Request
+//   - context.DeadlineExceeded: 504 Gateway Timeout, the request took longer than its timeout
+//
+// Any other error is a 500 Internal Server Error with message, so internal details are not exposed.
+func responseError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, internal.ErrValidation):
+		responseValidation(w, err)
+	case errors.Is(err, internal.ErrNotFound):
+		response.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, internal.ErrConflict):
+		response.Error(w, http.StatusConflict, err.Error())
+	case errors.Is(err, internal.ErrInvalidReference):
+		response.Error(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, internal.ErrPageInvalid),
+		errors.Is(err, internal.ErrDatetimeInvalid),
+		errors.Is(err, internal.ErrMoneyInvalid),
+		errors.Is(err, internal.ErrCustomerConditionInvalid):
+		response.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		response.Error(w, http.StatusGatewayTimeout, "request timed out")
+	default:
+		response.Error(w, http.StatusInternalServerError, message)
+	}
+}
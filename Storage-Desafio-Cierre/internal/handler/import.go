@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"app/internal"
+
+	"github.com/bootcamp-go/web/response"
+)
+
+// ImportBodyMax is the maximum size in bytes of the body of an import request
+const ImportBodyMax = 10 << 20
+
+// ImportRowErrorJSON is a struct that represents the error of a row of an import in JSON format
+type ImportRowErrorJSON struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportResultJSON is a struct that represents the summary of an import in JSON format
+type ImportResultJSON struct {
+	DryRun   bool                 `json:"dry_run"`
+	Inserted int                  `json:"inserted"`
+	Skipped  int                  `json:"skipped"`
+	Failed   int                  `json:"failed"`
+	Errors   []ImportRowErrorJSON `json:"errors"`
+}
+
+// ImportOptionsFromRequest returns the import options of the query params of an import request:
+//   - mode: atomic to save every row or none, partial to save the rows that do not fail, atomic if missing
+//   - dry_run: if true the rows are checked without saving them
+func ImportOptionsFromRequest(r *http.Request) (o internal.ImportOptions, err error) {
+	o.Mode = internal.ImportAtomic
+	if v := r.URL.Query().Get("mode"); v != "" {
+		o.Mode = internal.ImportMode(v)
+	}
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		o.DryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			err = fmt.Errorf("invalid dry_run %q", v)
+		}
+	}
+	return
+}
+
+// importRowsFromRequest decodes the rows of the body of an import request, up to ImportBodyMax bytes:
+// a CSV with a header of the JSON names of the fields of T if the Content-Type is text/csv,
+// otherwise a JSON array of T. A row that can not be decoded has its error, the body is only an error
+// if it is not a CSV or a JSON array.
+func importRowsFromRequest[T any](w http.ResponseWriter, r *http.Request) (rows []internal.ImportRow[T], err error) {
+	body := http.MaxBytesReader(w, r.Body, ImportBodyMax)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		return importRowsFromCSV[T](body)
+	}
+
+	var items []json.RawMessage
+	err = json.NewDecoder(body).Decode(&items)
+	if err != nil {
+		err = fmt.Errorf("error deserializing request body: %w", err)
+		return
+	}
+	rows = make([]internal.ImportRow[T], len(items))
+	for ix, item := range items {
+		rows[ix].Row = ix + 1
+		if errRow := json.Unmarshal(item, &rows[ix].Entity); errRow != nil {
+			rows[ix].Err = fmt.Errorf("%w: %v", internal.ErrImportRowMalformed, errRow)
+		}
+	}
+	return
+}
+
+// importRowsFromCSV decodes the rows of a CSV with a header of the JSON names of the fields of T.
+// The values are read as in the JSON of T, unescaping the strings escaped by csvEscape, so the CSV exported
+// by a list can be imported back, and an empty value keeps the zero value of the field.
+func importRowsFromCSV[T any](body io.Reader) (rows []internal.ImportRow[T], err error) {
+	cr := csv.NewReader(body)
+	header, err := cr.Read()
+	if err != nil {
+		err = fmt.Errorf("error reading csv header: %w", err)
+		return
+	}
+
+	// - header: the JSON names of the fields of T, strings are quoted in the JSON of the rows
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	quoted := make(map[string]bool)
+	for ix := 0; ix < t.NumField(); ix++ {
+		name, _, _ := strings.Cut(t.Field(ix).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			quoted[name] = t.Field(ix).Type.Kind() == reflect.String
+		}
+	}
+	for _, name := range header {
+		if _, ok := quoted[name]; !ok {
+			err = fmt.Errorf("unknown csv column %q", name)
+			return
+		}
+	}
+
+	// - rows
+	for n := 1; ; n++ {
+		record, errRead := cr.Read()
+		if errors.Is(errRead, io.EOF) {
+			break
+		}
+		row := internal.ImportRow[T]{Row: n}
+		var pe *csv.ParseError
+		switch {
+		case errors.As(errRead, &pe):
+			row.Err = fmt.Errorf("%w: %v", internal.ErrImportRowMalformed, pe.Err)
+		case errRead != nil:
+			err = fmt.Errorf("error reading csv: %w", errRead)
+			return
+		default:
+			item := make(map[string]json.RawMessage)
+			for ix, value := range record {
+				if value == "" {
+					continue
+				}
+				switch {
+				case quoted[header[ix]]:
+					value = strconv.Quote(csvUnescape(value))
+				case !json.Valid([]byte(value)):
+					value = strconv.Quote(value)
+				}
+				item[header[ix]] = json.RawMessage(value)
+			}
+			b, _ := json.Marshal(item)
+			if errRow := json.Unmarshal(b, &row.Entity); errRow != nil {
+				row.Err = fmt.Errorf("%w: %v", internal.ErrImportRowMalformed, errRow)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return
+}
+
+// responseImport writes the summary of an import: 200 OK, or 422 Unprocessable Entity if rows failed
+// in an atomic import, so nothing was saved
+func responseImport(w http.ResponseWriter, o internal.ImportOptions, res internal.ImportResult, entity string) {
+	code, message := http.StatusOK, entity+" imported"
+	switch {
+	case res.Failed > 0 && o.Mode == internal.ImportAtomic:
+		code, message = http.StatusUnprocessableEntity, entity+" not imported, no row was saved"
+	case o.DryRun:
+		message = entity + " checked, no row was saved"
+	}
+
+	rJSON := ImportResultJSON{
+		DryRun:   res.DryRun,
+		Inserted: res.Inserted,
+		Skipped:  res.Skipped,
+		Failed:   res.Failed,
+		Errors:   make([]ImportRowErrorJSON, len(res.Errors)),
+	}
+	for ix, v := range res.Errors {
+		rJSON.Errors[ix] = ImportRowErrorJSON{Row: v.Row, Error: v.Err.Error()}
+	}
+	response.JSON(w, code, map[string]any{
+		"message": message,
+		"data":    rJSON,
+	})
+}
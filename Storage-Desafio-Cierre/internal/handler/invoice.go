@@ -1,42 +1,72 @@
 package handler
 
 import (
+	"html/template"
 	"net/http"
+	"strconv"
+	"time"
 
 	"app/internal"
 
 	"github.com/bootcamp-go/web/request"
 	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
 )
 
 // NewInvoicesDefault returns a new InvoicesDefault
-func NewInvoicesDefault(sv internal.ServiceInvoice) *InvoicesDefault {
-	return &InvoicesDefault{sv: sv}
+func NewInvoicesDefault(sv internal.ServiceInvoice, loc *time.Location) *InvoicesDefault {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &InvoicesDefault{sv: sv, loc: loc, tpl: templatesDocument}
 }
 
 // InvoicesDefault is a struct that returns the invoice handlers
 type InvoicesDefault struct {
 	// sv is the invoice's service
 	sv internal.ServiceInvoice
+	// loc is the store time zone, datetimes without an offset are read in it
+	loc *time.Location
+	// tpl are the templates the invoice documents are rendered with
+	tpl *template.Template
+}
+
+// formatDatetime returns the datetime as RFC 3339 in the time zone loc, or empty if it is zero
+func formatDatetime(t time.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(loc).Format(time.RFC3339)
 }
 
 // InvoiceJSON is a struct that represents a invoice in JSON format
 type InvoiceJSON struct {
-	Id         int     `json:"id"`
-	Datetime   string  `json:"datetime"`
-	Total      float64 `json:"total"`
-	CustomerId int     `json:"customer_id"`
+	Id         int            `json:"id"`
+	Datetime   string         `json:"datetime"`
+	Total      internal.Money `json:"total"`
+	CustomerId int            `json:"customer_id"`
 }
+
 // GetAll returns all invoices
 func (h *InvoicesDefault) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - page
+		page, err := PageFromRequest(r, internal.InvoiceSortFields)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
 
 		// process
-		invoices, err := h.sv.FindAll()
+		invoices, err := h.sv.FindAll(r.Context(), page)
+		if err != nil {
+			responseError(w, err, "error getting invoices")
+			return
+		}
+		total, err := h.sv.Count(r.Context())
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error getting invoices")
+			responseError(w, err, "error getting invoices")
 			return
 		}
 
@@ -46,39 +76,74 @@ func (h *InvoicesDefault) GetAll() http.HandlerFunc {
 		for ix, v := range invoices {
 			ivJSON[ix] = InvoiceJSON{
 				Id:         v.Id,
-				Datetime:   v.Datetime,
+				Datetime:   formatDatetime(v.Datetime, h.loc),
 				Total:      v.Total,
 				CustomerId: v.CustomerId,
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
+		responseList(w, r, map[string]any{
 			"message": "invoices found",
 			"data":    ivJSON,
+			"paging":  NewPagingJSON(page, total, nextCursor(page, invoices)),
 		})
 	}
 }
 
+// UpdateTotal updates the total of every invoice from its sales
 func (h *InvoicesDefault) UpdateTotal() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		err := h.sv.UpdateTotal()
+		err := h.sv.UpdateTotal(r.Context())
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error updating invoices total")
+			responseError(w, err, "error updating invoices total")
 			return
 		}
 
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "invoices total updated",
-			"data": nil,
+			"data":    nil,
+		})
+	}
+}
+
+// UpdateTotalById updates the total of an invoice from its sales
+func (h *InvoicesDefault) UpdateTotalById() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		i, err := h.sv.UpdateTotalById(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error updating invoice total")
+			return
+		}
+
+		// response
+		// - serialize
+		iv := InvoiceJSON{
+			Id:         i.Id,
+			Datetime:   formatDatetime(i.Datetime, h.loc),
+			Total:      i.Total,
+			CustomerId: i.CustomerId,
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "invoice total updated",
+			"data":    iv,
 		})
 	}
 }
 
-// RequestBodyInvoice is a struct that represents the request body for a invoice
+// RequestBodyInvoice is a struct that represents the request body for a invoice,
+// the total is computed from its sales so a total in the body is ignored
 type RequestBodyInvoice struct {
-	Datetime   string  `json:"datetime"`
-	Total      float64 `json:"total"`
-	CustomerId int     `json:"customer_id"`
+	Datetime   string `json:"datetime"`
+	CustomerId int    `json:"customer_id"`
 }
+
 // Create creates a new invoice
 func (h *InvoicesDefault) Create() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -91,19 +156,57 @@ func (h *InvoicesDefault) Create() http.HandlerFunc {
 			return
 		}
 
+		// - datetime
+		datetime, err := internal.ParseDatetime(reqBody.Datetime, h.loc)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
 		// process
 		// - deserialize
 		i := internal.Invoice{
 			InvoiceAttributes: internal.InvoiceAttributes{
-				Datetime:   reqBody.Datetime,
-				Total:      reqBody.Total,
+				Datetime:   datetime,
 				CustomerId: reqBody.CustomerId,
 			},
 		}
 		// - save
-		err = h.sv.Save(&i)
+		err = h.sv.Save(r.Context(), &i)
+		if err != nil {
+			responseError(w, err, "error saving invoice")
+			return
+		}
+
+		// response
+		// - serialize
+		iv := InvoiceJSON{
+			Id:         i.Id,
+			Datetime:   formatDatetime(i.Datetime, h.loc),
+			Total:      i.Total,
+			CustomerId: i.CustomerId,
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "invoice created",
+			"data":    iv,
+		})
+	}
+}
+
+// GetById returns an invoice by id
+func (h *InvoicesDefault) GetById() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		i, err := h.sv.FindById(r.Context(), id)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error saving invoice")
+			responseError(w, err, "error getting invoice")
 			return
 		}
 
@@ -111,11 +214,213 @@ func (h *InvoicesDefault) Create() http.HandlerFunc {
 		// - serialize
 		iv := InvoiceJSON{
 			Id:         i.Id,
-			Datetime:   i.Datetime,
+			Datetime:   formatDatetime(i.Datetime, h.loc),
 			Total:      i.Total,
 			CustomerId: i.CustomerId,
 		}
 		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "invoice found",
+			"data":    iv,
+		})
+	}
+}
+
+// Update replaces an invoice
+func (h *InvoicesDefault) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		// - body
+		var reqBody RequestBodyInvoice
+		err = request.JSON(r, &reqBody)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "error parsing request body")
+			return
+		}
+
+		// process
+		h.update(w, r, id, reqBody)
+	}
+}
+
+// Patch partially updates an invoice, keeping the fields missing in the body
+func (h *InvoicesDefault) Patch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		// - current invoice
+		i, err := h.sv.FindById(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error getting invoice")
+			return
+		}
+		// - body: decoded over the current values
+		reqBody := RequestBodyInvoice{
+			Datetime:   formatDatetime(i.Datetime, h.loc),
+			CustomerId: i.CustomerId,
+		}
+		err = request.JSON(r, &reqBody)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "error parsing request body")
+			return
+		}
+
+		// process
+		h.update(w, r, id, reqBody)
+	}
+}
+
+// update saves the invoice of the request body and writes the response
+func (h *InvoicesDefault) update(w http.ResponseWriter, r *http.Request, id int, reqBody RequestBodyInvoice) {
+	// - datetime
+	datetime, err := internal.ParseDatetime(reqBody.Datetime, h.loc)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// - deserialize
+	i := internal.Invoice{
+		Id: id,
+		InvoiceAttributes: internal.InvoiceAttributes{
+			Datetime:   datetime,
+			CustomerId: reqBody.CustomerId,
+		},
+	}
+	// - update
+	err = h.sv.Update(r.Context(), &i)
+	if err != nil {
+		responseError(w, err, "error updating invoice")
+		return
+	}
+
+	// response
+	// - serialize
+	iv := InvoiceJSON{
+		Id:         i.Id,
+		Datetime:   formatDatetime(i.Datetime, h.loc),
+		Total:      i.Total,
+		CustomerId: i.CustomerId,
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "invoice updated",
+		"data":    iv,
+	})
+}
+
+// Delete deletes an invoice and its sales
+func (h *InvoicesDefault) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		err = h.sv.Delete(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error deleting invoice")
+			return
+		}
+
+		// response
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RequestBodyCheckoutLine is a product and its quantity in a checkout
+type RequestBodyCheckoutLine struct {
+	ProductId int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// RequestBodyCheckout is a struct that represents the request body for a checkout
+type RequestBodyCheckout struct {
+	Datetime   string                    `json:"datetime"`
+	CustomerId int                       `json:"customer_id"`
+	Lines      []RequestBodyCheckoutLine `json:"lines"`
+}
+
+// InvoiceLinesJSON is a struct that represents an invoice with its sales in JSON format
+type InvoiceLinesJSON struct {
+	InvoiceJSON
+	Lines []SaleJSON `json:"lines"`
+}
+
+// Checkout creates an invoice with its sales in a single transaction,
+// computing the total from the prices of the products
+func (h *InvoicesDefault) Checkout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - body
+		var reqBody RequestBodyCheckout
+		err := request.JSON(r, &reqBody)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "error parsing request body")
+			return
+		}
+
+		// - datetime
+		datetime, err := internal.ParseDatetime(reqBody.Datetime, h.loc)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// process
+		// - deserialize
+		i := internal.Invoice{
+			InvoiceAttributes: internal.InvoiceAttributes{
+				Datetime:   datetime,
+				CustomerId: reqBody.CustomerId,
+			},
+		}
+		s := make([]internal.Sale, len(reqBody.Lines))
+		for ix, v := range reqBody.Lines {
+			s[ix] = internal.Sale{
+				SaleAttributes: internal.SaleAttributes{
+					Quantity:  v.Quantity,
+					ProductId: v.ProductId,
+				},
+			}
+		}
+		// - save
+		err = h.sv.Checkout(r.Context(), &i, s)
+		if err != nil {
+			responseError(w, err, "error saving invoice")
+			return
+		}
+
+		// response
+		// - serialize
+		iv := InvoiceLinesJSON{
+			InvoiceJSON: InvoiceJSON{
+				Id:         i.Id,
+				Datetime:   formatDatetime(i.Datetime, h.loc),
+				Total:      i.Total,
+				CustomerId: i.CustomerId,
+			},
+			Lines: make([]SaleJSON, len(s)),
+		}
+		for ix, v := range s {
+			iv.Lines[ix] = SaleJSON{
+				Id:        v.Id,
+				Quantity:  v.Quantity,
+				ProductId: v.ProductId,
+				InvoiceId: v.InvoiceId,
+				UnitPrice: v.UnitPrice,
+			}
+		}
+		response.JSON(w, http.StatusCreated, map[string]any{
 			"message": "invoice created",
 			"data":    iv,
 		})
@@ -0,0 +1,282 @@
+package handler_test
+
+import (
+	"app/internal"
+	"app/internal/handler"
+	"app/internal/repository/memory"
+	"app/internal/service"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewInvoicesRouter returns the router of NewRouter over a memory storage
+// with a customer and two products priced 2.5 and 1, in a store time zone of UTC-3
+func NewInvoicesRouter(t *testing.T) (rt *chi.Mux, st *memory.Store) {
+	st = memory.NewStore()
+	c := internal.Customer{CustomerAttributes: internal.CustomerAttributes{FirstName: "Michael", LastName: "Jordan", Condition: 1}}
+	require.NoError(t, memory.NewCustomersMemory(st).Save(context.Background(), &c))
+	rpProduct := memory.NewProductsMemory(st)
+	for _, p := range []internal.Product{
+		{ProductAttributes: internal.ProductAttributes{Description: "Milk", Price: 250, Stock: 10}},
+		{ProductAttributes: internal.ProductAttributes{Description: "Bread", Price: 100, Stock: 5}},
+	} {
+		require.NoError(t, rpProduct.Save(context.Background(), &p))
+	}
+
+	loc := time.FixedZone("UTC-3", -3*60*60)
+	rt = NewRouter(st, loc)
+	return
+}
+
+func TestInvoicesDefault_Create(t *testing.T) {
+	t.Run("should read datetimes in the store time zone", func(t *testing.T) {
+		cases := map[string]string{
+			"2024-01-02":                "2024-01-02T00:00:00-03:00",
+			"2024-01-02 10:00:00":       "2024-01-02T10:00:00-03:00",
+			"2024-01-02T15:00:00Z":      "2024-01-02T12:00:00-03:00",
+			"2024-01-02T10:00:00-03:00": "2024-01-02T10:00:00-03:00",
+		}
+		for datetime, expected := range cases {
+			rt, _ := NewInvoicesRouter(t)
+			body := `{"customer_id": 1, "datetime": "` + datetime + `"}`
+			request := httptest.NewRequest(http.MethodPost, "/invoices", strings.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			response := httptest.NewRecorder()
+
+			rt.ServeHTTP(response, request)
+
+			expectedResponse := `{"message": "invoice created", "data": {"id": 1, "datetime": "` + expected + `", "total": 0, "customer_id": 1}}`
+			assert.Equal(t, http.StatusOK, response.Code, datetime)
+			assert.JSONEq(t, expectedResponse, response.Body.String(), datetime)
+		}
+	})
+
+	t.Run("should ignore a total in the body", func(t *testing.T) {
+		rt, _ := NewInvoicesRouter(t)
+		body := `{"customer_id": 1, "datetime": "2024-01-02", "total": 999}`
+		request := httptest.NewRequest(http.MethodPost, "/invoices", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Body.String(), `"total":0`)
+	})
+
+	t.Run("should reject a malformed datetime", func(t *testing.T) {
+		rt, st := NewInvoicesRouter(t)
+		body := `{"customer_id": 1, "datetime": "15/05/2022"}`
+		request := httptest.NewRequest(http.MethodPost, "/invoices", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Contains(t, response.Body.String(), "invalid datetime")
+		n, err := memory.NewInvoicesMemory(st).Count(context.Background())
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
+
+func TestInvoicesDefault_Update(t *testing.T) {
+	t.Run("should ignore a total in the body and keep the one of the sales", func(t *testing.T) {
+		for _, method := range []string{http.MethodPut, http.MethodPatch} {
+			rt, st := NewInvoicesRouter(t)
+			i := internal.Invoice{InvoiceAttributes: internal.InvoiceAttributes{CustomerId: 1, Datetime: time.Date(2024, 1, 2, 13, 0, 0, 0, time.UTC)}}
+			s := []internal.Sale{{SaleAttributes: internal.SaleAttributes{ProductId: 1, Quantity: 2}}}
+			require.NoError(t, memory.NewInvoicesMemory(st).SaveWithSales(context.Background(), &i, s))
+			body := `{"customer_id": 1, "datetime": "2024-01-03 10:00:00", "total": 999}`
+			request := httptest.NewRequest(method, "/invoices/1", strings.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			response := httptest.NewRecorder()
+
+			rt.ServeHTTP(response, request)
+
+			expectedResponse := `{"message": "invoice updated", "data": {"id": 1, "datetime": "2024-01-03T10:00:00-03:00", "total": 5, "customer_id": 1}}`
+			assert.Equal(t, http.StatusOK, response.Code, method)
+			assert.JSONEq(t, expectedResponse, response.Body.String(), method)
+			iv, err := memory.NewInvoicesMemory(st).FindById(context.Background(), 1)
+			assert.NoError(t, err)
+			assert.Equal(t, internal.Money(500), iv.Total, method)
+		}
+	})
+}
+
+func TestInvoicesDefault_Delete(t *testing.T) {
+	t.Run("should delete the invoice without a body", func(t *testing.T) {
+		rt, st := NewInvoicesRouter(t)
+		i := internal.Invoice{InvoiceAttributes: internal.InvoiceAttributes{CustomerId: 1}}
+		require.NoError(t, memory.NewInvoicesMemory(st).Save(context.Background(), &i))
+		request := httptest.NewRequest(http.MethodDelete, "/invoices/1", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusNoContent, response.Code)
+		assert.Empty(t, response.Body.String())
+		n, err := memory.NewInvoicesMemory(st).Count(context.Background())
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
+
+func TestInvoicesDefault_Checkout(t *testing.T) {
+	t.Run("should create the invoice with its lines and compute the total", func(t *testing.T) {
+		rt, _ := NewInvoicesRouter(t)
+		body := `{"customer_id": 1, "datetime": "2024-01-02 10:00:00", "lines": [{"product_id": 1, "quantity": 2}, {"product_id": 2, "quantity": 3}]}`
+		request := httptest.NewRequest(http.MethodPost, "/checkout", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{
+			"message": "invoice created",
+			"data": {
+				"id": 1, "datetime": "2024-01-02T10:00:00-03:00", "total": 8, "customer_id": 1,
+				"lines": [
+					{"id": 1, "quantity": 2, "product_id": 1, "invoice_id": 1, "unit_price": 2.5},
+					{"id": 2, "quantity": 3, "product_id": 2, "invoice_id": 1, "unit_price": 1}
+				]
+			}
+		}`
+		assert.Equal(t, http.StatusCreated, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should save nothing if a line references a missing product", func(t *testing.T) {
+		rt, st := NewInvoicesRouter(t)
+		body := `{"customer_id": 1, "lines": [{"product_id": 1, "quantity": 2}, {"product_id": 99, "quantity": 1}]}`
+		request := httptest.NewRequest(http.MethodPost, "/checkout", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+		n, err := memory.NewSalesMemory(st).Count(context.Background())
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("should reject a checkout without lines", func(t *testing.T) {
+		rt, _ := NewInvoicesRouter(t)
+		request := httptest.NewRequest(http.MethodPost, "/checkout", strings.NewReader(`{"customer_id": 1, "lines": []}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+		assert.Contains(t, response.Body.String(), `{"field":"lines","message":"must have at least one line"}`)
+	})
+}
+
+func TestInvoicesDefault_UpdateTotalById(t *testing.T) {
+	t.Run("should set the total from the sales", func(t *testing.T) {
+		rt, st := NewInvoicesRouter(t)
+		i := internal.Invoice{InvoiceAttributes: internal.InvoiceAttributes{CustomerId: 1, Total: 10000}}
+		require.NoError(t, memory.NewInvoicesMemory(st).Save(context.Background(), &i))
+		request := httptest.NewRequest(http.MethodPut, "/invoices/1/total", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "invoice total updated", "data": {"id": 1, "datetime": "", "total": 0, "customer_id": 1}}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should return not found", func(t *testing.T) {
+		rt, _ := NewInvoicesRouter(t)
+		request := httptest.NewRequest(http.MethodPut, "/invoices/99/total", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+}
+
+func TestInvoicesDefault_GetDocument(t *testing.T) {
+	// checkout saves an invoice of 2 milks and 3 breads on 2024-01-02 10:00 UTC-3
+	checkout := func(t *testing.T, st *memory.Store) {
+		i := internal.Invoice{InvoiceAttributes: internal.InvoiceAttributes{
+			CustomerId: 1, Datetime: time.Date(2024, 1, 2, 13, 0, 0, 0, time.UTC),
+		}}
+		s := []internal.Sale{
+			{SaleAttributes: internal.SaleAttributes{ProductId: 1, Quantity: 2}},
+			{SaleAttributes: internal.SaleAttributes{ProductId: 2, Quantity: 3}},
+		}
+		require.NoError(t, memory.NewInvoicesMemory(st).SaveWithSales(context.Background(), &i, s))
+	}
+
+	t.Run("should render the customer, lines and total", func(t *testing.T) {
+		rt, st := NewInvoicesRouter(t)
+		checkout(t, st)
+		request := httptest.NewRequest(http.MethodGet, "/invoices/1/document", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "text/html; charset=utf-8", response.Header().Get("Content-Type"))
+		body := response.Body.String()
+		assert.Contains(t, body, "<h1>Invoice 1</h1>")
+		assert.Contains(t, body, "Date: 2024-01-02 10:00")
+		assert.Contains(t, body, "Customer: Michael Jordan (#1)")
+		assert.Regexp(t, `<td>Milk</td>\s*<td class="number">2.50</td>\s*<td class="number">2</td>\s*<td class="number">5.00</td>`, body)
+		assert.Regexp(t, `<td>Bread</td>\s*<td class="number">1.00</td>\s*<td class="number">3</td>\s*<td class="number">3.00</td>`, body)
+		assert.Regexp(t, `<td colspan="3">Total</td>\s*<td class="number">8.00</td>`, body)
+		assert.NotContains(t, body, "add up to")
+	})
+
+	t.Run("should render with the blocks of the custom templates", func(t *testing.T) {
+		_, st := NewInvoicesRouter(t)
+		checkout(t, st)
+		dir := t.TempDir()
+		custom := `{{define "header"}}<h1>Fantasy Products</h1><p>Bill to {{.Customer.LastName}}</p>{{end}}`
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "custom.html"), []byte(custom), 0o644))
+		tpl, err := handler.ParseTemplates(dir)
+		require.NoError(t, err)
+		hd := handler.NewInvoicesDefault(service.NewInvoicesDefault(memory.NewInvoicesMemory(st)), nil)
+		hd.SetTemplates(tpl)
+		request := httptest.NewRequest(http.MethodGet, "/invoices/1/document", nil)
+		request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, &chi.Context{
+			URLParams: chi.RouteParams{Keys: []string{"id"}, Values: []string{"1"}},
+		}))
+		response := httptest.NewRecorder()
+
+		hd.GetDocument()(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		body := response.Body.String()
+		assert.Contains(t, body, "<h1>Fantasy Products</h1><p>Bill to Jordan</p>")
+		assert.NotContains(t, body, "<h1>Invoice 1</h1>")
+		assert.Contains(t, body, "<td>Milk</td>")
+	})
+
+	t.Run("should return not found", func(t *testing.T) {
+		rt, _ := NewInvoicesRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/invoices/99/document", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+		assert.Contains(t, response.Body.String(), "invoice not found")
+	})
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"app/internal"
+)
+
+// Timeout returns a middleware that cancels the context of each request after d,
+// so the queries of a slow request are cancelled and it is answered with 504 Gateway Timeout.
+// A zero d disables the timeout.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ActorHeader is the request header with who makes the changes of the request, e.g. a user name.
+const ActorHeader = "X-User"
+
+// Actor is a middleware that sets the actor of the context of each request from ActorHeader,
+// so the changes recorded by the repositories, e.g. the condition history, say who made them.
+func Actor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actor := r.Header.Get(ActorHeader); actor != "" {
+			r = r.WithContext(internal.WithActor(r.Context(), actor))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
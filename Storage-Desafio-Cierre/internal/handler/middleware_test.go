@@ -0,0 +1,39 @@
+package handler_test
+
+import (
+	"app/internal/handler"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Run("should set a deadline on the context of the request", func(t *testing.T) {
+		var deadline time.Time
+		var ok bool
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, ok = r.Context().Deadline()
+		})
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		handler.Timeout(time.Minute)(next).ServeHTTP(httptest.NewRecorder(), request)
+
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+	})
+
+	t.Run("should not set a deadline when it is disabled", func(t *testing.T) {
+		var ok bool
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok = r.Context().Deadline()
+		})
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		handler.Timeout(0)(next).ServeHTTP(httptest.NewRecorder(), request)
+
+		assert.False(t, ok)
+	})
+}
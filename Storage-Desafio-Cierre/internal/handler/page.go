@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal"
+)
+
+const (
+	// PageLimitDefault is the limit of a list when the request does not set one.
+	PageLimitDefault = 100
+	// PageLimitMax is the maximum limit of a list.
+	PageLimitMax = 1000
+)
+
+// PagingJSON is the paging metadata of a list response
+type PagingJSON struct {
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Sort       string `json:"sort"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// cursorJSON is the content of an opaque cursor
+type cursorJSON struct {
+	Sort  string `json:"s"`
+	Value any    `json:"v"`
+	Id    int    `json:"id"`
+}
+
+// PageFromRequest returns the page of the query params of a list request:
+//   - limit: maximum number of items, between 1 and PageLimitMax
+//   - offset: number of items to skip
+//   - sort: field to sort by, prefixed by - for descending order, e.g. -price
+//   - cursor: next_cursor of the previous page, it replaces offset and must keep the same sort
+func PageFromRequest(r *http.Request, sortFields []string) (p internal.Page, err error) {
+	query := r.URL.Query()
+
+	// - limit
+	p.Limit = PageLimitDefault
+	if v := query.Get("limit"); v != "" {
+		p.Limit, err = strconv.Atoi(v)
+		if err != nil || p.Limit < 1 || p.Limit > PageLimitMax {
+			err = fmt.Errorf("%w: limit must be between 1 and %d", internal.ErrPageInvalid, PageLimitMax)
+			return
+		}
+	}
+
+	// - offset
+	if v := query.Get("offset"); v != "" {
+		p.Offset, err = strconv.Atoi(v)
+		if err != nil || p.Offset < 0 {
+			err = fmt.Errorf("%w: offset must be a positive number", internal.ErrPageInvalid)
+			return
+		}
+	}
+
+	// - sort
+	sort := query.Get("sort")
+	p.Sort, p.Desc = strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+	if !slices.Contains(sortFields, p.SortField()) {
+		err = fmt.Errorf("%w: sort must be one of %s", internal.ErrPageInvalid, strings.Join(sortFields, ", "))
+		return
+	}
+
+	// - cursor
+	if v := query.Get("cursor"); v != "" {
+		var c cursorJSON
+		b, errDecode := base64.RawURLEncoding.DecodeString(v)
+		if errDecode == nil {
+			errDecode = json.Unmarshal(b, &c)
+		}
+		if errDecode != nil || c.Sort != sort {
+			err = fmt.Errorf("%w: invalid cursor", internal.ErrPageInvalid)
+			return
+		}
+		// - cursor: datetimes are encoded as RFC 3339 strings
+		if s, ok := c.Value.(string); ok {
+			if t, errParse := time.Parse(time.RFC3339Nano, s); errParse == nil {
+				c.Value = t
+			}
+		}
+		p.After = &internal.Cursor{Value: c.Value, Id: c.Id}
+	}
+
+	return
+}
+
+// NewPagingJSON returns the paging metadata of a page.
+// next is the cursor of the last item if the page is full, or nil if there are no more items.
+func NewPagingJSON(p internal.Page, total int, next *internal.Cursor) (pg PagingJSON) {
+	pg = PagingJSON{
+		Limit:  p.Limit,
+		Offset: p.Offset,
+		Sort:   p.SortField(),
+		Total:  total,
+	}
+	if p.Desc {
+		pg.Sort = "-" + pg.Sort
+	}
+	if p.After != nil {
+		pg.Offset = 0
+	}
+
+	if next != nil {
+		sort := p.Sort
+		if p.Desc {
+			sort = "-" + sort
+		}
+		// - conditions are sorted by their number, as they are stored, not by their JSON name
+		value := next.Value
+		if c, ok := value.(internal.CustomerCondition); ok {
+			value = int(c)
+		}
+		b, _ := json.Marshal(cursorJSON{Sort: sort, Value: value, Id: next.Id})
+		pg.NextCursor = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return
+}
+
+// nextCursor returns the cursor after the last item if the page is full, otherwise nil.
+func nextCursor[T interface{ SortValue(field string) any }](p internal.Page, items []T) (c *internal.Cursor) {
+	if p.Limit == 0 || len(items) < p.Limit {
+		return
+	}
+	last := items[len(items)-1]
+	id, _ := last.SortValue("id").(int)
+	c = &internal.Cursor{Value: last.SortValue(p.SortField()), Id: id}
+	return
+}
@@ -2,41 +2,60 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"app/internal"
 
 	"github.com/bootcamp-go/web/request"
 	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
 )
 
 // NewProductsDefault returns a new ProductsDefault
-func NewProductsDefault(sv internal.ServiceProduct) *ProductsDefault {
-	return &ProductsDefault{sv: sv}
+func NewProductsDefault(sv internal.ServiceProduct, loc *time.Location) *ProductsDefault {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &ProductsDefault{sv: sv, loc: loc}
 }
 
 // ProductsDefault is a struct that returns the product handlers
 type ProductsDefault struct {
 	// sv is the product's service
 	sv internal.ServiceProduct
+	// loc is the store time zone, datetimes without an offset are read in it
+	loc *time.Location
 }
 
 // ProductJSON is a struct that represents a product in JSON format
 type ProductJSON struct {
-	Id          int     `json:"id"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Id          int            `json:"id"`
+	Description string         `json:"description"`
+	Price       internal.Money `json:"price"`
+	Stock       int            `json:"stock"`
 }
 
 // GetAll returns all products
 func (h *ProductsDefault) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - page
+		page, err := PageFromRequest(r, internal.ProductSortFields)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
 
 		// process
-		p, err := h.sv.FindAll()
+		p, err := h.sv.FindAll(r.Context(), page)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error getting products")
+			responseError(w, err, "error getting products")
+			return
+		}
+		total, err := h.sv.Count(r.Context())
+		if err != nil {
+			responseError(w, err, "error getting products")
 			return
 		}
 
@@ -48,36 +67,59 @@ func (h *ProductsDefault) GetAll() http.HandlerFunc {
 				Id:          v.Id,
 				Description: v.Description,
 				Price:       v.Price,
+				Stock:       v.Stock,
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
+		responseList(w, r, map[string]any{
 			"message": "products found",
 			"data":    pJSON,
+			"paging":  NewPagingJSON(page, total, nextCursor(page, p)),
 		})
 	}
 }
 
+// ProductAmountSoldResponseDto is a struct that represents a product of the top sold in JSON format.
+// Total is the former name of Units, kept for the existing consumers.
 type ProductAmountSoldResponseDto struct {
-	Description string  `json:"description"`
-	Total       float64 `json:"total"`
+	Description  string         `json:"description"`
+	Total        int            `json:"total"`
+	Units        int            `json:"units"`
+	Revenue      internal.Money `json:"revenue"`
+	RevenueShare float64        `json:"revenue_share"`
 }
 
+// GetTopProducts returns the products ranked by the quantity sold or the revenue, with their percentage
+// of the revenue of every product sold, filtered by the query params of ReportFilterFromRequest and:
+//   - rank_by: units or revenue, units if missing
 func (h *ProductsDefault) GetTopProducts() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		productAmount, err := h.sv.FindTopProductsByAmount(5)
+		f, err := ReportFilterFromRequest(r, h.loc)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error get top products")
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		q := internal.TopProductsQuery{ReportFilter: f, RankBy: internal.RankByUnits}
+		if v := r.URL.Query().Get("rank_by"); v != "" {
+			q.RankBy = internal.ProductRanking(v)
+		}
+
+		productAmount, err := h.sv.FindTopProductsByAmount(r.Context(), q)
+		if err != nil {
+			responseError(w, err, "error get top products")
 			return
 		}
 
 		products := make([]ProductAmountSoldResponseDto, len(productAmount))
 		for index, v := range productAmount {
 			products[index] = ProductAmountSoldResponseDto{
-				Description: v.Description,
-				Total:       v.Total,
+				Description:  v.Description,
+				Total:        v.Units,
+				Units:        v.Units,
+				Revenue:      v.Revenue,
+				RevenueShare: round(v.Share*100, 2),
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
+		responseList(w, r, map[string]any{
 			"message": "products found",
 			"data":    products,
 		})
@@ -85,9 +127,11 @@ func (h *ProductsDefault) GetTopProducts() http.HandlerFunc {
 }
 
 // RequestBodyProduct is a struct that represents the request body for a product
+// The stock is only read on create, afterwards it changes through sales and stock adjustments
 type RequestBodyProduct struct {
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Description string         `json:"description"`
+	Price       internal.Money `json:"price"`
+	Stock       int            `json:"stock"`
 }
 
 // Create creates a new product
@@ -108,12 +152,13 @@ func (h *ProductsDefault) Create() http.HandlerFunc {
 			ProductAttributes: internal.ProductAttributes{
 				Description: reqBody.Description,
 				Price:       reqBody.Price,
+				Stock:       reqBody.Stock,
 			},
 		}
 		// - save
-		err = h.sv.Save(&p)
+		err = h.sv.Save(r.Context(), &p)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error creating product")
+			responseError(w, err, "error creating product")
 			return
 		}
 
@@ -123,6 +168,7 @@ func (h *ProductsDefault) Create() http.HandlerFunc {
 			Id:          p.Id,
 			Description: p.Description,
 			Price:       p.Price,
+			Stock:       p.Stock,
 		}
 		response.JSON(w, http.StatusCreated, map[string]any{
 			"message": "product created",
@@ -130,3 +176,188 @@ func (h *ProductsDefault) Create() http.HandlerFunc {
 		})
 	}
 }
+
+// Import creates the products of a CSV or a JSON array of ProductJSON, the id is optional,
+// see importRowsFromRequest and ImportOptionsFromRequest
+func (h *ProductsDefault) Import() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		o, err := ImportOptionsFromRequest(r)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// - body
+		rowsJSON, err := importRowsFromRequest[ProductJSON](w, r)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// process
+		// - deserialize
+		rows := make([]internal.ImportRow[internal.Product], len(rowsJSON))
+		for ix, v := range rowsJSON {
+			rows[ix] = internal.ImportRow[internal.Product]{
+				Row: v.Row,
+				Entity: internal.Product{
+					Id: v.Entity.Id,
+					ProductAttributes: internal.ProductAttributes{
+						Description: v.Entity.Description,
+						Price:       v.Entity.Price,
+						Stock:       v.Entity.Stock,
+					},
+				},
+				Err: v.Err,
+			}
+		}
+		// - import
+		res, err := h.sv.Import(r.Context(), rows, o)
+		if err != nil {
+			responseError(w, err, "error importing products")
+			return
+		}
+
+		// response
+		responseImport(w, o, res, "products")
+	}
+}
+
+// GetById returns a product by id
+func (h *ProductsDefault) GetById() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		p, err := h.sv.FindById(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error getting product")
+			return
+		}
+
+		// response
+		// - serialize
+		pr := ProductJSON{
+			Id:          p.Id,
+			Description: p.Description,
+			Price:       p.Price,
+			Stock:       p.Stock,
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "product found",
+			"data":    pr,
+		})
+	}
+}
+
+// Update replaces a product
+// Invoices keep their total, the new price is not applied to them
+func (h *ProductsDefault) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		// - body
+		var reqBody RequestBodyProduct
+		err = request.JSON(r, &reqBody)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "error parsing request body")
+			return
+		}
+
+		// process
+		h.update(w, r, id, reqBody)
+	}
+}
+
+// Patch partially updates a product, keeping the fields missing in the body
+func (h *ProductsDefault) Patch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		// - current product
+		p, err := h.sv.FindById(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error getting product")
+			return
+		}
+		// - body: decoded over the current values
+		reqBody := RequestBodyProduct{
+			Description: p.Description,
+			Price:       p.Price,
+		}
+		err = request.JSON(r, &reqBody)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "error parsing request body")
+			return
+		}
+
+		// process
+		h.update(w, r, id, reqBody)
+	}
+}
+
+// update saves the product of the request body and writes the response
+func (h *ProductsDefault) update(w http.ResponseWriter, r *http.Request, id int, reqBody RequestBodyProduct) {
+	// - deserialize
+	p := internal.Product{
+		Id: id,
+		ProductAttributes: internal.ProductAttributes{
+			Description: reqBody.Description,
+			Price:       reqBody.Price,
+		},
+	}
+	// - update
+	err := h.sv.Update(r.Context(), &p)
+	if err != nil {
+		responseError(w, err, "error updating product")
+		return
+	}
+
+	// response
+	// - serialize
+	pr := ProductJSON{
+		Id:          p.Id,
+		Description: p.Description,
+		Price:       p.Price,
+		Stock:       p.Stock,
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "product updated",
+		"data":    pr,
+	})
+}
+
+// Delete deletes a product without sales
+func (h *ProductsDefault) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		err = h.sv.Delete(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error deleting product")
+			return
+		}
+
+		// response
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,364 @@
+package handler_test
+
+import (
+	"app/internal"
+	"app/internal/repository/memory"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewProductsRouter returns the router of NewRouter over a memory storage
+// with a product sold in an invoice of 2024-01-31 and a product without sales
+func NewProductsRouter(t *testing.T) (rt *chi.Mux, st *memory.Store) {
+	st = memory.NewStore()
+	c := internal.Customer{CustomerAttributes: internal.CustomerAttributes{FirstName: "Michael", LastName: "Jordan", Condition: 1}}
+	require.NoError(t, memory.NewCustomersMemory(st).Save(context.Background(), &c))
+	rpProduct := memory.NewProductsMemory(st)
+	for _, p := range []internal.Product{
+		{ProductAttributes: internal.ProductAttributes{Description: "Milk", Price: 250, Stock: 10}},
+		{ProductAttributes: internal.ProductAttributes{Description: "Bread", Price: 100, Stock: 5}},
+	} {
+		require.NoError(t, rpProduct.Save(context.Background(), &p))
+	}
+	i := internal.Invoice{InvoiceAttributes: internal.InvoiceAttributes{
+		CustomerId: c.Id, Total: 500, Datetime: time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC),
+	}}
+	require.NoError(t, memory.NewInvoicesMemory(st).Save(context.Background(), &i))
+	s := internal.Sale{SaleAttributes: internal.SaleAttributes{Quantity: 2, ProductId: 1, InvoiceId: i.Id}}
+	require.NoError(t, memory.NewSalesMemory(st).Save(context.Background(), &s))
+
+	rt = NewRouter(st, time.UTC)
+	return
+}
+
+func TestProductsDefault_Patch(t *testing.T) {
+	t.Run("should update the price without changing invoice totals", func(t *testing.T) {
+		rt, st := NewProductsRouter(t)
+		request := httptest.NewRequest(http.MethodPatch, "/products/1", strings.NewReader(`{"price": 3}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "product updated", "data": {"id": 1, "description": "Milk", "price": 3, "stock": 8}}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+		invoices, err := memory.NewInvoicesMemory(st).FindAll(context.Background(), internal.Page{})
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Money(500), invoices[0].Total)
+	})
+}
+
+func TestProductsDefault_Delete(t *testing.T) {
+	t.Run("should refuse to delete a product with sales", func(t *testing.T) {
+		rt, _ := NewProductsRouter(t)
+		request := httptest.NewRequest(http.MethodDelete, "/products/1", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusConflict, response.Code)
+	})
+
+	t.Run("should delete a product without sales", func(t *testing.T) {
+		rt, _ := NewProductsRouter(t)
+		request := httptest.NewRequest(http.MethodDelete, "/products/2", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusNoContent, response.Code)
+		assert.Empty(t, response.Body.String())
+	})
+
+	t.Run("should return not found", func(t *testing.T) {
+		rt, _ := NewProductsRouter(t)
+		request := httptest.NewRequest(http.MethodDelete, "/products/99", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+}
+
+func TestProductsDefault_AdjustStock(t *testing.T) {
+	t.Run("should add to the stock and record the movement", func(t *testing.T) {
+		rt, _ := NewProductsRouter(t)
+		request := httptest.NewRequest(http.MethodPost, "/products/2/stock", strings.NewReader(`{"quantity": 20, "reason": "delivery"}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusCreated, response.Code)
+		assert.Contains(t, response.Body.String(), `"quantity":20,"reason":"delivery","balance":25`)
+
+		request = httptest.NewRequest(http.MethodGet, "/products/2/stock/movements?sort=-id", nil)
+		response = httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Body.String(), `"quantity":20,"reason":"delivery","balance":25`)
+		assert.Contains(t, response.Body.String(), `"quantity":5,"reason":"initial","balance":5`)
+		assert.Contains(t, response.Body.String(), `"total":2`)
+	})
+
+	t.Run("should reject taking more than the stock", func(t *testing.T) {
+		rt, _ := NewProductsRouter(t)
+		request := httptest.NewRequest(http.MethodPost, "/products/1/stock", strings.NewReader(`{"quantity": -9, "reason": "damaged"}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"status": "Conflict", "message": "conflict: insufficient stock: product 1 has 8, 9 requested"}`
+		assert.Equal(t, http.StatusConflict, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should reject an adjustment without quantity and reason", func(t *testing.T) {
+		rt, _ := NewProductsRouter(t)
+		request := httptest.NewRequest(http.MethodPost, "/products/1/stock", strings.NewReader(`{}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"status": "Unprocessable Entity", "message": "validation failed", "errors": [{"field": "quantity", "message": "can not be zero"}, {"field": "reason", "message": "is required"}]}`
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+}
+
+func TestProductsDefault_GetLowStock(t *testing.T) {
+	t.Run("should return the products up to the threshold ordered by stock", func(t *testing.T) {
+		rt, _ := NewProductsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/products/low-stock?threshold=8", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "products found", "data": [
+			{"id": 2, "description": "Bread", "price": 1, "stock": 5},
+			{"id": 1, "description": "Milk", "price": 2.5, "stock": 8}
+		]}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+}
+
+func TestProductsDefault_GetTopProducts(t *testing.T) {
+	t.Run("should only count the sales of the period", func(t *testing.T) {
+		rt, _ := NewProductsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/products/top-sold?from=2024-01-01&to=2024-01-31", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "products found", "data": [{"description": "Milk", "total": 2, "units": 2, "revenue": 5, "revenue_share": 100}]}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+
+		request = httptest.NewRequest(http.MethodGet, "/products/top-sold?from=2024-02-01", nil)
+		response = httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse = `{"message": "products found", "data": []}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should rank by units or revenue with the revenue share", func(t *testing.T) {
+		rt, st := NewProductsRouter(t)
+		s := internal.Sale{SaleAttributes: internal.SaleAttributes{Quantity: 4, ProductId: 2, InvoiceId: 1}}
+		require.NoError(t, memory.NewSalesMemory(st).Save(context.Background(), &s))
+		request := httptest.NewRequest(http.MethodGet, "/products/top-sold", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "products found", "data": [
+			{"description": "Bread", "total": 4, "units": 4, "revenue": 4, "revenue_share": 44.44},
+			{"description": "Milk", "total": 2, "units": 2, "revenue": 5, "revenue_share": 55.56}
+		]}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+
+		request = httptest.NewRequest(http.MethodGet, "/products/top-sold?rank_by=revenue&limit=1", nil)
+		response = httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse = `{"message": "products found", "data": [
+			{"description": "Milk", "total": 2, "units": 2, "revenue": 5, "revenue_share": 55.56}
+		]}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should reject an invalid filter", func(t *testing.T) {
+		rt, _ := NewProductsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/products/top-sold?limit=0&from=2024-02-01&to=2024-01-01&rank_by=price", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"status": "Unprocessable Entity", "message": "validation failed", "errors": [
+			{"field": "limit", "message": "must be between 1 and 100"},
+			{"field": "to", "message": "must be after from"},
+			{"field": "rank_by", "message": "must be units or revenue"}
+		]}`
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should reject a malformed condition", func(t *testing.T) {
+		rt, _ := NewProductsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/products/top-sold?condition=vip", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+// NewBasketsRouter returns the router of NewProductsRouter with bread added to the invoice of milk
+// and an invoice of milk alone
+func NewBasketsRouter(t *testing.T) (rt *chi.Mux) {
+	rt, st := NewProductsRouter(t)
+	i := internal.Invoice{InvoiceAttributes: internal.InvoiceAttributes{
+		CustomerId: 1, Total: 250, Datetime: time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC),
+	}}
+	require.NoError(t, memory.NewInvoicesMemory(st).Save(context.Background(), &i))
+	for _, s := range []internal.Sale{
+		{SaleAttributes: internal.SaleAttributes{Quantity: 1, ProductId: 2, InvoiceId: 1}},
+		{SaleAttributes: internal.SaleAttributes{Quantity: 1, ProductId: 1, InvoiceId: i.Id}},
+	} {
+		require.NoError(t, memory.NewSalesMemory(st).Save(context.Background(), &s))
+	}
+	return
+}
+
+func TestProductsDefault_GetAssociations(t *testing.T) {
+	t.Run("should return both directions of the pairs", func(t *testing.T) {
+		rt := NewBasketsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/reports/products/associations", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "associations found", "data": [
+			{"product_id": 2, "description": "Bread", "with_product_id": 1, "with_description": "Milk",
+				"invoices": 1, "support": 0.5, "confidence": 1, "lift": 1},
+			{"product_id": 1, "description": "Milk", "with_product_id": 2, "with_description": "Bread",
+				"invoices": 1, "support": 0.5, "confidence": 0.5, "lift": 1}
+		]}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should return the products bought with a product over the thresholds", func(t *testing.T) {
+		rt := NewBasketsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/products/2/bought-with?min_confidence=0.6", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Body.String(), `"with_description":"Milk"`)
+
+		request = httptest.NewRequest(http.MethodGet, "/products/1/bought-with?min_confidence=0.6", nil)
+		response = httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, `{"message": "associations found", "data": []}`, response.Body.String())
+	})
+
+	t.Run("should reject a product that does not exist and thresholds out of range", func(t *testing.T) {
+		rt := NewBasketsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/products/9/bought-with", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+
+		request = httptest.NewRequest(http.MethodGet, "/reports/products/associations?min_support=2", nil)
+		response = httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+	})
+}
+
+func TestProductsDefault_Import(t *testing.T) {
+	t.Run("should import a csv at once", func(t *testing.T) {
+		rt, st := NewProductsRouter(t)
+		body := "description,price,stock\nButter,3.10,4\n\"Eggs, dozen\",2,\n"
+		request := httptest.NewRequest(http.MethodPost, "/products/import", strings.NewReader(body))
+		request.Header.Set("Content-Type", "text/csv")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "products imported", "data": {"dry_run": false, "inserted": 2, "skipped": 0, "failed": 0, "errors": []}}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+		p, err := memory.NewProductsMemory(st).FindById(context.Background(), 4)
+		assert.NoError(t, err)
+		assert.Equal(t, "Eggs, dozen", p.Description)
+		assert.Equal(t, internal.Money(200), p.Price)
+	})
+
+	t.Run("should save no row of an atomic import with failed rows", func(t *testing.T) {
+		rt, st := NewProductsRouter(t)
+		body := `[{"description": "Butter", "price": 3.1}, {"description": "", "price": 2}, {"id": 1, "description": "Milk", "price": 1}, {"price": "x"}]`
+		request := httptest.NewRequest(http.MethodPost, "/products/import", strings.NewReader(body))
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "products not imported, no row was saved", "data": {"dry_run": false, "inserted": 0, "skipped": 2, "failed": 2, "errors": [
+			{"row": 2, "error": "validation failed: description is required"},
+			{"row": 4, "error": "malformed row: invalid money amount: \"\\\"x\\\"\""}
+		]}}`
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+		n, err := memory.NewProductsMemory(st).Count(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+	})
+
+	t.Run("should report the rows that would fail in a dry run", func(t *testing.T) {
+		rt, st := NewProductsRouter(t)
+		body := `[{"description": "Butter", "price": 3.1}, {"id": 1, "description": "Milk", "price": 1}]`
+		request := httptest.NewRequest(http.MethodPost, "/products/import?mode=partial&dry_run=true", strings.NewReader(body))
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "products checked, no row was saved", "data": {"dry_run": true, "inserted": 1, "skipped": 0, "failed": 1, "errors": [
+			{"row": 2, "error": "conflict: memory: duplicate id"}
+		]}}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+		n, err := memory.NewProductsMemory(st).Count(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+	})
+}
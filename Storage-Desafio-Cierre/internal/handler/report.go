@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"app/internal"
+
+	"github.com/bootcamp-go/web/response"
+)
+
+// ReportLimitDefault is the limit of a ranking report when the request does not set one
+const ReportLimitDefault = 5
+
+// NewReportsDefault returns a new ReportsDefault
+func NewReportsDefault(sv internal.ServiceReport, loc *time.Location) *ReportsDefault {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &ReportsDefault{sv: sv, loc: loc}
+}
+
+// ReportsDefault is a struct that returns the report handlers
+type ReportsDefault struct {
+	// sv is the report's service
+	sv internal.ServiceReport
+	// loc is the store time zone, the report periods are read in it
+	loc *time.Location
+}
+
+// ReportFilterFromRequest returns the report filter of the query params of a report request,
+// the datetimes are read as the ones of the request bodies, see internal.ParseDatetime:
+//   - limit: maximum number of rows of a ranking, ReportLimitDefault if missing
+//   - from: start of the period of the invoices, inclusive
+//   - to: end of the period of the invoices, exclusive, a date includes the whole day
+//   - condition: condition of the customers, active or inactive
+//   - at: datetime the condition of the customers is taken at, the current one if missing
+//
+// Only malformed params are an error here, their rules are validated by the services.
+func ReportFilterFromRequest(r *http.Request, loc *time.Location) (f internal.ReportFilter, err error) {
+	query := r.URL.Query()
+
+	// - limit
+	f.Limit = ReportLimitDefault
+	if v := query.Get("limit"); v != "" {
+		f.Limit, err = strconv.Atoi(v)
+		if err != nil {
+			err = fmt.Errorf("invalid limit %q", v)
+			return
+		}
+	}
+
+	// - period
+	f.From, err = internal.ParseDatetime(query.Get("from"), loc)
+	if err != nil {
+		return
+	}
+	v := query.Get("to")
+	f.To, err = internal.ParseDatetime(v, loc)
+	if err != nil {
+		return
+	}
+	if len(v) == len(time.DateOnly) {
+		f.To = f.To.AddDate(0, 0, 1)
+	}
+
+	// - condition
+	if v := query.Get("condition"); v != "" {
+		var c internal.CustomerCondition
+		c, err = internal.ParseCustomerCondition(v)
+		if err != nil {
+			return
+		}
+		f.Condition = &c
+	}
+	f.ConditionAt, err = internal.ParseDatetime(query.Get("at"), loc)
+	return
+}
+
+// RevenueBucketJSON is a struct that represents a bucket of the revenue report in JSON format
+type RevenueBucketJSON struct {
+	Start     string                      `json:"start"`
+	Condition *internal.CustomerCondition `json:"condition,omitempty"`
+	Invoices  int                         `json:"invoices"`
+	Units     int                         `json:"units"`
+	Revenue   internal.Money              `json:"revenue"`
+}
+
+// GetRevenue returns the invoice count, units sold and revenue of every bucket of a period,
+// filtered by the query params of ReportFilterFromRequest except limit, from and to are required:
+//   - interval: size of the buckets, day, week or month, day if missing
+//   - by_condition: split each bucket by the condition of the customers if true
+func (h *ReportsDefault) GetRevenue() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - query
+		f, err := ReportFilterFromRequest(r, h.loc)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		q := internal.RevenueQuery{ReportFilter: f, Interval: internal.RevenueDay}
+		if v := r.URL.Query().Get("interval"); v != "" {
+			q.Interval = internal.RevenueInterval(v)
+		}
+		if v := r.URL.Query().Get("by_condition"); v != "" {
+			q.ByCondition, err = strconv.ParseBool(v)
+			if err != nil {
+				response.Error(w, http.StatusBadRequest, "invalid by_condition")
+				return
+			}
+		}
+
+		// process
+		b, err := h.sv.FindRevenue(r.Context(), q)
+		if err != nil {
+			responseError(w, err, "error getting revenue")
+			return
+		}
+
+		// response
+		// - serialize
+		bJSON := make([]RevenueBucketJSON, len(b))
+		for ix, v := range b {
+			bJSON[ix] = RevenueBucketJSON{
+				Start:     v.Start.In(h.loc).Format(time.DateOnly),
+				Condition: v.Condition,
+				Invoices:  v.Invoices,
+				Units:     v.Units,
+				Revenue:   v.Revenue,
+			}
+		}
+		responseList(w, r, map[string]any{
+			"message": "revenue found",
+			"data":    bJSON,
+		})
+	}
+}
+
+// RFMSegmentJSON is a struct that represents a segment of the RFM report in JSON format
+type RFMSegmentJSON struct {
+	Segment   internal.RFMSegment `json:"segment"`
+	Customers int                 `json:"customers"`
+	Revenue   internal.Money      `json:"revenue"`
+}
+
+// CustomerRFMJSON is a struct that represents the RFM of a customer in JSON format
+type CustomerRFMJSON struct {
+	CustomerId  int                 `json:"customer_id"`
+	FirstName   string              `json:"first_name"`
+	LastName    string              `json:"last_name"`
+	LastInvoice string              `json:"last_invoice"`
+	RecencyDays int                 `json:"recency_days"`
+	Frequency   int                 `json:"frequency"`
+	Monetary    internal.Money      `json:"monetary"`
+	Scores      string              `json:"scores"`
+	Segment     internal.RFMSegment `json:"segment"`
+}
+
+// GetRFM returns the recency, frequency and monetary value of the customers with invoices, their segment and
+// the number of customers and revenue of every segment, filtered by the query params of ReportFilterFromRequest
+// except limit, the recency is taken at to, or now if missing. The score thresholds are pairs of numbers:
+//   - recency: days since the last invoice up to which recency scores 3 and 2
+//   - frequency: invoices from which frequency scores 2 and 3
+//   - monetary: total of the invoices from which monetary scores 2 and 3
+//
+// internal.RFMThresholdsDefault are used for the missing ones. As CSV, it returns the customers with their segment.
+func (h *ReportsDefault) GetRFM() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - query
+		f, err := ReportFilterFromRequest(r, h.loc)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		t, err := rfmThresholdsFromRequest(r)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		_, err = FormatFromRequest(r)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// process
+		rfm, err := h.sv.FindRFM(r.Context(), internal.RFMQuery{ReportFilter: f, Thresholds: t})
+		if err != nil {
+			responseError(w, err, "error getting rfm")
+			return
+		}
+
+		// response
+		// - serialize
+		sJSON := make([]RFMSegmentJSON, len(rfm.Segments))
+		for ix, v := range rfm.Segments {
+			sJSON[ix] = RFMSegmentJSON{Segment: v.Segment, Customers: v.Customers, Revenue: v.Revenue}
+		}
+		cJSON := make([]CustomerRFMJSON, len(rfm.Customers))
+		for ix, v := range rfm.Customers {
+			cJSON[ix] = CustomerRFMJSON{
+				CustomerId:  v.CustomerId,
+				FirstName:   v.FirstName,
+				LastName:    v.LastName,
+				LastInvoice: formatDatetime(v.LastInvoice, h.loc),
+				RecencyDays: v.RecencyDays,
+				Frequency:   v.Invoices,
+				Monetary:    v.Total,
+				Scores:      fmt.Sprintf("%d%d%d", v.Recency, v.Frequency, v.Monetary),
+				Segment:     v.Segment,
+			}
+		}
+		// - csv: the customers, their segment is in each row
+		if format, _ := FormatFromRequest(r); format == FormatCSV {
+			responseCSV(w, r, cJSON)
+			return
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "rfm found",
+			"data": map[string]any{
+				"segments":  sJSON,
+				"customers": cJSON,
+			},
+		})
+	}
+}
+
+// rfmThresholdsFromRequest returns the score thresholds of the query params of an RFM request,
+// the default ones for the missing params
+func rfmThresholdsFromRequest(r *http.Request) (t internal.RFMThresholds, err error) {
+	t = internal.RFMThresholdsDefault
+
+	// - recency
+	pair, ok, err := pairFromQuery(r, "recency")
+	for ix := 0; ok && err == nil && ix < len(pair); ix++ {
+		t.RecencyDays[ix], err = strconv.Atoi(pair[ix])
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid recency %q", r.URL.Query().Get("recency"))
+		return
+	}
+	// - frequency
+	pair, ok, err = pairFromQuery(r, "frequency")
+	for ix := 0; ok && err == nil && ix < len(pair); ix++ {
+		t.Frequency[ix], err = strconv.Atoi(pair[ix])
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid frequency %q", r.URL.Query().Get("frequency"))
+		return
+	}
+	// - monetary
+	pair, ok, err = pairFromQuery(r, "monetary")
+	for ix := 0; ok && err == nil && ix < len(pair); ix++ {
+		t.Monetary[ix], err = internal.ParseMoney(pair[ix])
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid monetary %q", r.URL.Query().Get("monetary"))
+		return
+	}
+	return
+}
+
+// pairFromQuery returns the two comma separated values of a query param, ok is false if it is missing
+func pairFromQuery(r *http.Request, name string) (pair [2]string, ok bool, err error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return
+	}
+	parts := strings.Split(v, ",")
+	if len(parts) != len(pair) {
+		err = fmt.Errorf("invalid %s %q", name, v)
+		return
+	}
+	for ix := range parts {
+		pair[ix] = strings.TrimSpace(parts[ix])
+	}
+	ok = true
+	return
+}
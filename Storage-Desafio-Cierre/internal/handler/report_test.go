@@ -0,0 +1,184 @@
+package handler_test
+
+import (
+	"app/internal"
+	"app/internal/repository/memory"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewReportsRouter returns the router of NewRouter over a memory storage
+// with invoices of an active customer on 2024-01-01 and 2024-01-03 and of an inactive one on 2024-01-10
+func NewReportsRouter(t *testing.T) (rt *chi.Mux) {
+	st := memory.NewStore()
+	rpCustomer := memory.NewCustomersMemory(st)
+	for _, c := range []internal.Customer{
+		{CustomerAttributes: internal.CustomerAttributes{FirstName: "Michael", LastName: "Jordan", Condition: internal.CustomerActive}},
+		{CustomerAttributes: internal.CustomerAttributes{FirstName: "Scottie", LastName: "Pippen", Condition: internal.CustomerInactive}},
+	} {
+		require.NoError(t, rpCustomer.Save(context.Background(), &c))
+	}
+	p := internal.Product{ProductAttributes: internal.ProductAttributes{Description: "Milk", Price: 250, Stock: 10}}
+	require.NoError(t, memory.NewProductsMemory(st).Save(context.Background(), &p))
+	for _, v := range []struct {
+		customerId int
+		day        int
+		quantity   int
+	}{
+		{customerId: 1, day: 1, quantity: 2},
+		{customerId: 1, day: 3, quantity: 1},
+		{customerId: 2, day: 10, quantity: 3},
+	} {
+		i := internal.Invoice{InvoiceAttributes: internal.InvoiceAttributes{
+			CustomerId: v.customerId, Total: internal.Money(v.quantity) * p.Price, Datetime: time.Date(2024, 1, v.day, 10, 0, 0, 0, time.UTC),
+		}}
+		require.NoError(t, memory.NewInvoicesMemory(st).Save(context.Background(), &i))
+		s := internal.Sale{SaleAttributes: internal.SaleAttributes{Quantity: v.quantity, ProductId: p.Id, InvoiceId: i.Id}}
+		require.NoError(t, memory.NewSalesMemory(st).Save(context.Background(), &s))
+	}
+
+	rt = NewRouter(st, time.UTC)
+	return
+}
+
+func TestReportsDefault_GetRevenue(t *testing.T) {
+	t.Run("should fill the days without invoices with zero", func(t *testing.T) {
+		rt := NewReportsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/reports/revenue?from=2024-01-01&to=2024-01-03", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "revenue found", "data": [
+			{"start": "2024-01-01", "invoices": 1, "units": 2, "revenue": 5},
+			{"start": "2024-01-02", "invoices": 0, "units": 0, "revenue": 0},
+			{"start": "2024-01-03", "invoices": 1, "units": 1, "revenue": 2.5}
+		]}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should split the weeks by condition", func(t *testing.T) {
+		rt := NewReportsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/reports/revenue?from=2024-01-03&to=2024-01-14&interval=week&by_condition=true", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "revenue found", "data": [
+			{"start": "2024-01-01", "condition": "inactive", "invoices": 0, "units": 0, "revenue": 0},
+			{"start": "2024-01-01", "condition": "active", "invoices": 1, "units": 1, "revenue": 2.5},
+			{"start": "2024-01-08", "condition": "inactive", "invoices": 1, "units": 3, "revenue": 7.5},
+			{"start": "2024-01-08", "condition": "active", "invoices": 0, "units": 0, "revenue": 0}
+		]}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should return csv with format=csv", func(t *testing.T) {
+		rt := NewReportsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/reports/revenue?from=2024-01-03&to=2024-01-03&by_condition=true&format=csv", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := "start,condition,invoices,units,revenue\n" +
+			"2024-01-03,inactive,0,0,0.00\n" +
+			"2024-01-03,active,1,1,2.50\n"
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should require the period and a known interval", func(t *testing.T) {
+		rt := NewReportsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/reports/revenue?interval=year", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"status": "Unprocessable Entity", "message": "validation failed", "errors": [
+			{"field": "from", "message": "is required"},
+			{"field": "to", "message": "is required"},
+			{"field": "interval", "message": "must be day, week or month"}
+		]}`
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+}
+
+func TestReportsDefault_GetRFM(t *testing.T) {
+	t.Run("should segment the customers with the thresholds of the request", func(t *testing.T) {
+		rt := NewReportsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/reports/customers/rfm?to=2024-01-10&recency=5,10&frequency=2,3&monetary=5,10", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "rfm found", "data": {
+			"segments": [
+				{"segment": "champions", "customers": 0, "revenue": 0},
+				{"segment": "loyal", "customers": 0, "revenue": 0},
+				{"segment": "new", "customers": 1, "revenue": 7.5},
+				{"segment": "promising", "customers": 0, "revenue": 0},
+				{"segment": "needs attention", "customers": 1, "revenue": 7.5},
+				{"segment": "at risk", "customers": 0, "revenue": 0},
+				{"segment": "dormant", "customers": 0, "revenue": 0}
+			],
+			"customers": [
+				{"customer_id": 1, "first_name": "Michael", "last_name": "Jordan", "last_invoice": "2024-01-03T10:00:00Z",
+					"recency_days": 7, "frequency": 2, "monetary": 7.5, "scores": "222", "segment": "needs attention"},
+				{"customer_id": 2, "first_name": "Scottie", "last_name": "Pippen", "last_invoice": "2024-01-10T10:00:00Z",
+					"recency_days": 0, "frequency": 1, "monetary": 7.5, "scores": "312", "segment": "new"}
+			]
+		}}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should count the recency up to the last day of the period", func(t *testing.T) {
+		st := memory.NewStore()
+		c := internal.Customer{CustomerAttributes: internal.CustomerAttributes{FirstName: "Michael", LastName: "Jordan", Condition: internal.CustomerActive}}
+		require.NoError(t, memory.NewCustomersMemory(st).Save(context.Background(), &c))
+		i := internal.Invoice{InvoiceAttributes: internal.InvoiceAttributes{CustomerId: c.Id, Total: 250, Datetime: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)}}
+		require.NoError(t, memory.NewInvoicesMemory(st).Save(context.Background(), &i))
+		rt := NewRouter(st, time.UTC)
+		request := httptest.NewRequest(http.MethodGet, "/reports/customers/rfm?to=2024-01-10", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Body.String(), `"last_invoice":"2024-01-03T00:00:00Z","recency_days":7,`)
+	})
+
+	t.Run("should reject thresholds out of order", func(t *testing.T) {
+		rt := NewReportsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/reports/customers/rfm?recency=90,30", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"status": "Unprocessable Entity", "message": "validation failed", "errors": [
+			{"field": "recency", "message": "must be ascending"}
+		]}`
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+
+	t.Run("should reject malformed thresholds", func(t *testing.T) {
+		rt := NewReportsRouter(t)
+		request := httptest.NewRequest(http.MethodGet, "/reports/customers/rfm?frequency=2", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+}
@@ -0,0 +1,79 @@
+package handler_test
+
+import (
+	"app/internal/handler"
+	"app/internal/repository/memory"
+	"app/internal/service"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// NewRouter returns a router with the routes of the application backed by a memory storage,
+// with the datetimes read and written in the time zone loc
+func NewRouter(st *memory.Store, loc *time.Location) (rt *chi.Mux) {
+	// dependencies
+	hdCustomer := handler.NewCustomersDefault(service.NewCustomersDefault(memory.NewCustomersMemory(st)), loc)
+	hdProduct := handler.NewProductsDefault(service.NewProductsDefault(memory.NewProductsMemory(st)), loc)
+	hdInvoice := handler.NewInvoicesDefault(service.NewInvoicesDefault(memory.NewInvoicesMemory(st)), loc)
+	hdSale := handler.NewSalesDefault(service.NewSalesDefault(memory.NewSalesMemory(st)))
+	hdReport := handler.NewReportsDefault(service.NewReportsDefault(memory.NewReportsMemory(st)), loc)
+
+	// routes
+	rt = chi.NewRouter()
+	rt.Use(handler.Actor)
+	rt.Route("/customers", func(r chi.Router) {
+		r.Get("/", hdCustomer.GetAll())
+		r.Post("/", hdCustomer.Create())
+		r.Post("/import", hdCustomer.Import())
+		r.Get("/{id}", hdCustomer.GetById())
+		r.Put("/{id}", hdCustomer.Update())
+		r.Patch("/{id}", hdCustomer.Patch())
+		r.Delete("/{id}", hdCustomer.Delete())
+		r.Post("/{id}/activate", hdCustomer.Activate())
+		r.Post("/{id}/deactivate", hdCustomer.Deactivate())
+		r.Get("/{id}/condition-history", hdCustomer.GetConditionHistory())
+		r.Get("/top-active", hdCustomer.GetTopActiveCustomersByAmountSpent())
+		r.Get("/invoices-by-condition", hdCustomer.GetInvoicesByCondition())
+	})
+	rt.Route("/products", func(r chi.Router) {
+		r.Get("/", hdProduct.GetAll())
+		r.Post("/", hdProduct.Create())
+		r.Post("/import", hdProduct.Import())
+		r.Get("/top-sold", hdProduct.GetTopProducts())
+		r.Get("/low-stock", hdProduct.GetLowStock())
+		r.Get("/{id}", hdProduct.GetById())
+		r.Put("/{id}", hdProduct.Update())
+		r.Patch("/{id}", hdProduct.Patch())
+		r.Delete("/{id}", hdProduct.Delete())
+		r.Post("/{id}/stock", hdProduct.AdjustStock())
+		r.Get("/{id}/stock/movements", hdProduct.GetStockMovements())
+		r.Get("/{id}/bought-with", hdProduct.GetBoughtWith())
+	})
+	rt.Route("/invoices", func(r chi.Router) {
+		r.Get("/", hdInvoice.GetAll())
+		r.Post("/", hdInvoice.Create())
+		r.Put("/total", hdInvoice.UpdateTotal())
+		r.Get("/{id}", hdInvoice.GetById())
+		r.Put("/{id}", hdInvoice.Update())
+		r.Patch("/{id}", hdInvoice.Patch())
+		r.Delete("/{id}", hdInvoice.Delete())
+		r.Get("/{id}/document", hdInvoice.GetDocument())
+		r.Put("/{id}/total", hdInvoice.UpdateTotalById())
+	})
+	rt.Post("/checkout", hdInvoice.Checkout())
+	rt.Route("/sales", func(r chi.Router) {
+		r.Get("/", hdSale.GetAll())
+		r.Post("/", hdSale.Create())
+		r.Get("/{id}", hdSale.GetById())
+		r.Put("/{id}", hdSale.Update())
+		r.Patch("/{id}", hdSale.Patch())
+		r.Delete("/{id}", hdSale.Delete())
+	})
+	rt.Route("/reports", func(r chi.Router) {
+		r.Get("/revenue", hdReport.GetRevenue())
+		r.Get("/customers/rfm", hdReport.GetRFM())
+		r.Get("/products/associations", hdProduct.GetAssociations())
+	})
+	return
+}
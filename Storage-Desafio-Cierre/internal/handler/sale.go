@@ -2,11 +2,13 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
 	"app/internal"
 
 	"github.com/bootcamp-go/web/request"
 	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
 )
 
 // NewSalesDefault returns a new SalesDefault
@@ -22,22 +24,33 @@ type SalesDefault struct {
 
 // SaleJSON is a struct that represents a sale in JSON format
 type SaleJSON struct {
-	Id int `json:"id"`
-	Quantity int `json:"quantity"`
-	ProductId int `json:"product_id"`
-	InvoiceId int `json:"invoice_id"`
+	Id        int            `json:"id"`
+	Quantity  int            `json:"quantity"`
+	ProductId int            `json:"product_id"`
+	InvoiceId int            `json:"invoice_id"`
+	UnitPrice internal.Money `json:"unit_price"`
 }
 
 // GetAll returns all sales
 func (h *SalesDefault) GetAll() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
-		// ...
+		// - page
+		page, err := PageFromRequest(r, internal.SaleSortFields)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
 
 		// process
-		s, err := h.sv.FindAll()
+		s, err := h.sv.FindAll(r.Context(), page)
+		if err != nil {
+			responseError(w, err, "error getting sales")
+			return
+		}
+		total, err := h.sv.Count(r.Context())
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error getting sales")
+			responseError(w, err, "error getting sales")
 			return
 		}
 
@@ -47,24 +60,27 @@ func (h *SalesDefault) GetAll() http.HandlerFunc {
 		for ix, v := range s {
 			sJSON[ix] = SaleJSON{
 				Id:        v.Id,
-				Quantity: v.Quantity,
-				ProductId:  v.ProductId,
+				Quantity:  v.Quantity,
+				ProductId: v.ProductId,
 				InvoiceId: v.InvoiceId,
+				UnitPrice: v.UnitPrice,
 			}
 		}
-		response.JSON(w, http.StatusOK, map[string]any{
+		responseList(w, r, map[string]any{
 			"message": "sales found",
 			"data":    sJSON,
+			"paging":  NewPagingJSON(page, total, nextCursor(page, s)),
 		})
 	}
 }
 
 // RequestBodySale is a struct that represents the request body for a sale
 type RequestBodySale struct {
-	Quantity int `json:"quantity"`
+	Quantity  int `json:"quantity"`
 	ProductId int `json:"product_id"`
 	InvoiceId int `json:"invoice_id"`
 }
+
 // Create creates a new sale
 func (h *SalesDefault) Create() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -81,15 +97,15 @@ func (h *SalesDefault) Create() http.HandlerFunc {
 		// - deserialize
 		s := internal.Sale{
 			SaleAttributes: internal.SaleAttributes{
-				Quantity: reqBody.Quantity,
+				Quantity:  reqBody.Quantity,
 				ProductId: reqBody.ProductId,
 				InvoiceId: reqBody.InvoiceId,
 			},
 		}
 		// - save
-		err = h.sv.Save(&s)
+		err = h.sv.Save(r.Context(), &s)
 		if err != nil {
-			response.Error(w, http.StatusInternalServerError, "error saving sale")
+			responseError(w, err, "error saving sale")
 			return
 		}
 
@@ -97,13 +113,156 @@ func (h *SalesDefault) Create() http.HandlerFunc {
 		// - serialize
 		sa := SaleJSON{
 			Id:        s.Id,
-			Quantity: s.Quantity,
-			ProductId:  s.ProductId,
+			Quantity:  s.Quantity,
+			ProductId: s.ProductId,
 			InvoiceId: s.InvoiceId,
+			UnitPrice: s.UnitPrice,
 		}
 		response.JSON(w, http.StatusOK, map[string]any{
 			"message": "sale created",
 			"data":    sa,
 		})
 	}
-}
\ No newline at end of file
+}
+
+// GetById returns a sale by id
+func (h *SalesDefault) GetById() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		s, err := h.sv.FindById(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error getting sale")
+			return
+		}
+
+		// response
+		// - serialize
+		sa := SaleJSON{
+			Id:        s.Id,
+			Quantity:  s.Quantity,
+			ProductId: s.ProductId,
+			InvoiceId: s.InvoiceId,
+			UnitPrice: s.UnitPrice,
+		}
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "sale found",
+			"data":    sa,
+		})
+	}
+}
+
+// Update replaces a sale, recomputing the total of its invoice
+func (h *SalesDefault) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		// - body
+		var reqBody RequestBodySale
+		err = request.JSON(r, &reqBody)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "error parsing request body")
+			return
+		}
+
+		// process
+		h.update(w, r, id, reqBody)
+	}
+}
+
+// Patch partially updates a sale, keeping the fields missing in the body
+func (h *SalesDefault) Patch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		// - current sale
+		s, err := h.sv.FindById(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error getting sale")
+			return
+		}
+		// - body: decoded over the current values
+		reqBody := RequestBodySale{
+			Quantity:  s.Quantity,
+			ProductId: s.ProductId,
+			InvoiceId: s.InvoiceId,
+		}
+		err = request.JSON(r, &reqBody)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "error parsing request body")
+			return
+		}
+
+		// process
+		h.update(w, r, id, reqBody)
+	}
+}
+
+// update saves the sale of the request body and writes the response
+func (h *SalesDefault) update(w http.ResponseWriter, r *http.Request, id int, reqBody RequestBodySale) {
+	// - deserialize
+	s := internal.Sale{
+		Id: id,
+		SaleAttributes: internal.SaleAttributes{
+			Quantity:  reqBody.Quantity,
+			ProductId: reqBody.ProductId,
+			InvoiceId: reqBody.InvoiceId,
+		},
+	}
+	// - update
+	err := h.sv.Update(r.Context(), &s)
+	if err != nil {
+		responseError(w, err, "error updating sale")
+		return
+	}
+
+	// response
+	// - serialize
+	sa := SaleJSON{
+		Id:        s.Id,
+		Quantity:  s.Quantity,
+		ProductId: s.ProductId,
+		InvoiceId: s.InvoiceId,
+		UnitPrice: s.UnitPrice,
+	}
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "sale updated",
+		"data":    sa,
+	})
+}
+
+// Delete deletes a sale, recomputing the total of its invoice
+func (h *SalesDefault) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		err = h.sv.Delete(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error deleting sale")
+			return
+		}
+
+		// response
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
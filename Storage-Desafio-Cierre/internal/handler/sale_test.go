@@ -0,0 +1,164 @@
+package handler_test
+
+import (
+	"app/internal"
+	"app/internal/repository/memory"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewSalesRouter returns the router of NewRouter over a memory storage
+// with an invoice of two sales: 2 x 2.5 and 1 x 1
+func NewSalesRouter(t *testing.T) (rt *chi.Mux, st *memory.Store) {
+	st = memory.NewStore()
+	c := internal.Customer{CustomerAttributes: internal.CustomerAttributes{FirstName: "Michael", LastName: "Jordan", Condition: 1}}
+	require.NoError(t, memory.NewCustomersMemory(st).Save(context.Background(), &c))
+	rpProduct := memory.NewProductsMemory(st)
+	for _, p := range []internal.Product{
+		{ProductAttributes: internal.ProductAttributes{Description: "Milk", Price: 250, Stock: 10}},
+		{ProductAttributes: internal.ProductAttributes{Description: "Bread", Price: 100, Stock: 5}},
+	} {
+		require.NoError(t, rpProduct.Save(context.Background(), &p))
+	}
+	i := internal.Invoice{InvoiceAttributes: internal.InvoiceAttributes{CustomerId: c.Id, Total: 600}}
+	require.NoError(t, memory.NewInvoicesMemory(st).Save(context.Background(), &i))
+	rpSale := memory.NewSalesMemory(st)
+	for _, s := range []internal.Sale{
+		{SaleAttributes: internal.SaleAttributes{Quantity: 2, ProductId: 1, InvoiceId: i.Id}},
+		{SaleAttributes: internal.SaleAttributes{Quantity: 1, ProductId: 2, InvoiceId: i.Id}},
+	} {
+		require.NoError(t, rpSale.Save(context.Background(), &s))
+	}
+
+	rt = NewRouter(st, time.UTC)
+	return
+}
+
+func TestSalesDefault_Patch(t *testing.T) {
+	t.Run("should update the quantity and the invoice total", func(t *testing.T) {
+		rt, st := NewSalesRouter(t)
+		request := httptest.NewRequest(http.MethodPatch, "/sales/1", strings.NewReader(`{"quantity": 4}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "sale updated", "data": {"id": 1, "quantity": 4, "product_id": 1, "invoice_id": 1, "unit_price": 2.5}}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+		i, err := memory.NewInvoicesMemory(st).FindById(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Money(1100), i.Total)
+	})
+
+	t.Run("should keep the prices the sales were made at after a price change", func(t *testing.T) {
+		rt, st := NewSalesRouter(t)
+		p := internal.Product{Id: 2, ProductAttributes: internal.ProductAttributes{Description: "Bread", Price: 900}}
+		require.NoError(t, memory.NewProductsMemory(st).Update(context.Background(), &p))
+		request := httptest.NewRequest(http.MethodPatch, "/sales/1", strings.NewReader(`{"quantity": 4}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		i, err := memory.NewInvoicesMemory(st).FindById(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Money(1100), i.Total)
+	})
+
+	t.Run("should take the current price of a new product", func(t *testing.T) {
+		rt, st := NewSalesRouter(t)
+		request := httptest.NewRequest(http.MethodPatch, "/sales/1", strings.NewReader(`{"product_id": 2}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"message": "sale updated", "data": {"id": 1, "quantity": 2, "product_id": 2, "invoice_id": 1, "unit_price": 1}}`
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+		i, err := memory.NewInvoicesMemory(st).FindById(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Money(300), i.Total)
+	})
+
+	t.Run("should reject a quantity over the stock, keeping the stock", func(t *testing.T) {
+		rt, st := NewSalesRouter(t)
+		request := httptest.NewRequest(http.MethodPatch, "/sales/1", strings.NewReader(`{"quantity": 13}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"status": "Conflict", "message": "conflict: insufficient stock: product 1 has 10, 13 requested"}`
+		assert.Equal(t, http.StatusConflict, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+		p, err := memory.NewProductsMemory(st).FindById(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 8, p.Stock)
+	})
+
+	t.Run("should reject a missing product", func(t *testing.T) {
+		rt, _ := NewSalesRouter(t)
+		request := httptest.NewRequest(http.MethodPatch, "/sales/1", strings.NewReader(`{"product_id": 99}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+	})
+}
+
+func TestSalesDefault_Update(t *testing.T) {
+	t.Run("should reject a quantity that is not positive", func(t *testing.T) {
+		rt, _ := NewSalesRouter(t)
+		request := httptest.NewRequest(http.MethodPut, "/sales/1", strings.NewReader(`{"quantity": 0, "product_id": 1, "invoice_id": 1}`))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		expectedResponse := `{"status": "Unprocessable Entity", "message": "validation failed", "errors": [{"field": "quantity", "message": "must be positive"}]}`
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+		assert.JSONEq(t, expectedResponse, response.Body.String())
+	})
+}
+
+func TestSalesDefault_Delete(t *testing.T) {
+	t.Run("should delete the sale, update the invoice total and restore the stock", func(t *testing.T) {
+		rt, st := NewSalesRouter(t)
+		request := httptest.NewRequest(http.MethodDelete, "/sales/2", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusNoContent, response.Code)
+		assert.Empty(t, response.Body.String())
+		i, err := memory.NewInvoicesMemory(st).FindById(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, internal.Money(500), i.Total)
+		p, err := memory.NewProductsMemory(st).FindById(context.Background(), 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, p.Stock)
+	})
+
+	t.Run("should return not found", func(t *testing.T) {
+		rt, _ := NewSalesRouter(t)
+		request := httptest.NewRequest(http.MethodDelete, "/sales/99", nil)
+		response := httptest.NewRecorder()
+
+		rt.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"app/internal"
+
+	"github.com/bootcamp-go/web/request"
+	"github.com/bootcamp-go/web/response"
+	"github.com/go-chi/chi/v5"
+)
+
+// LowStockThresholdDefault is the threshold of the low stock report when the query param is missing
+const LowStockThresholdDefault = 10
+
+// StockMovementJSON is a struct that represents a stock movement in JSON format
+type StockMovementJSON struct {
+	Id        int    `json:"id"`
+	ProductId int    `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason"`
+	SaleId    int    `json:"sale_id,omitempty"`
+	Balance   int    `json:"balance"`
+	Datetime  string `json:"datetime"`
+}
+
+// RequestBodyStockAdjustment is a struct that represents the request body for a stock adjustment
+type RequestBodyStockAdjustment struct {
+	// Quantity is added to the stock, negative to take from it
+	Quantity int    `json:"quantity"`
+	Reason   string `json:"reason"`
+}
+
+// AdjustStock adds a quantity to the stock of a product, e.g. a delivery or a shrinkage
+func (h *ProductsDefault) AdjustStock() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		// - body
+		var reqBody RequestBodyStockAdjustment
+		err = request.JSON(r, &reqBody)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "error parsing request body")
+			return
+		}
+
+		// process
+		// - deserialize
+		m := internal.StockMovement{
+			StockMovementAttributes: internal.StockMovementAttributes{
+				ProductId: id,
+				Quantity:  reqBody.Quantity,
+				Reason:    reqBody.Reason,
+			},
+		}
+		// - adjust
+		err = h.sv.AdjustStock(r.Context(), &m)
+		if err != nil {
+			responseError(w, err, "error adjusting stock")
+			return
+		}
+
+		// response
+		// - serialize
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "stock adjusted",
+			"data":    h.stockMovementJSON(m),
+		})
+	}
+}
+
+// GetStockMovements returns the stock movements of a product
+func (h *ProductsDefault) GetStockMovements() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		// - page
+		page, err := PageFromRequest(r, internal.StockMovementSortFields)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// process
+		m, err := h.sv.FindStockMovements(r.Context(), id, page)
+		if err != nil {
+			responseError(w, err, "error getting stock movements")
+			return
+		}
+		total, err := h.sv.CountStockMovements(r.Context(), id)
+		if err != nil {
+			responseError(w, err, "error getting stock movements")
+			return
+		}
+
+		// response
+		// - serialize
+		mJSON := make([]StockMovementJSON, len(m))
+		for ix, v := range m {
+			mJSON[ix] = h.stockMovementJSON(v)
+		}
+		responseList(w, r, map[string]any{
+			"message": "stock movements found",
+			"data":    mJSON,
+			"paging":  NewPagingJSON(page, total, nextCursor(page, m)),
+		})
+	}
+}
+
+// GetLowStock returns the products with a stock up to the query param threshold, LowStockThresholdDefault if missing
+func (h *ProductsDefault) GetLowStock() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - query
+		threshold := LowStockThresholdDefault
+		if v := r.URL.Query().Get("threshold"); v != "" {
+			var err error
+			threshold, err = strconv.Atoi(v)
+			if err != nil || threshold < 0 {
+				response.Error(w, http.StatusBadRequest, "invalid threshold")
+				return
+			}
+		}
+
+		// process
+		p, err := h.sv.FindLowStock(r.Context(), threshold)
+		if err != nil {
+			responseError(w, err, "error getting products")
+			return
+		}
+
+		// response
+		// - serialize
+		pJSON := make([]ProductJSON, len(p))
+		for ix, v := range p {
+			pJSON[ix] = ProductJSON{
+				Id:          v.Id,
+				Description: v.Description,
+				Price:       v.Price,
+				Stock:       v.Stock,
+			}
+		}
+		responseList(w, r, map[string]any{
+			"message": "products found",
+			"data":    pJSON,
+		})
+	}
+}
+
+// stockMovementJSON returns the movement in JSON format, with its datetime in the store time zone
+func (h *ProductsDefault) stockMovementJSON(m internal.StockMovement) StockMovementJSON {
+	return StockMovementJSON{
+		Id:        m.Id,
+		ProductId: m.ProductId,
+		Quantity:  m.Quantity,
+		Reason:    m.Reason,
+		SaleId:    m.SaleId,
+		Balance:   m.Balance,
+		Datetime:  formatDatetime(m.Datetime, h.loc),
+	}
+}
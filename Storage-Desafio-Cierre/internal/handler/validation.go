@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"app/internal"
+
+	"github.com/bootcamp-go/web/response"
+)
+
+// FieldErrorJSON is a struct that represents a field error in JSON format
+type FieldErrorJSON struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorJSON is the body of a validation error response,
+// the body of response.Error with the field errors
+type ValidationErrorJSON struct {
+	Status  string           `json:"status"`
+	Message string           `json:"message"`
+	Errors  []FieldErrorJSON `json:"errors"`
+}
+
+// responseValidation writes a 422 Unprocessable Entity response with the field errors of err,
+// or with its message if it is not a internal.ValidationError
+func responseValidation(w http.ResponseWriter, err error) {
+	var ve *internal.ValidationError
+	errors.As(err, &ve)
+
+	body := ValidationErrorJSON{
+		Status:  http.StatusText(http.StatusUnprocessableEntity),
+		Message: internal.ErrValidation.Error(),
+		Errors:  []FieldErrorJSON{},
+	}
+	// - errors of the database have no field errors
+	if ve == nil {
+		body.Message = err.Error()
+	}
+	if ve != nil {
+		for _, f := range ve.Fields {
+			body.Errors = append(body.Errors, FieldErrorJSON{Field: f.Field, Message: f.Message})
+		}
+	}
+	response.JSON(w, http.StatusUnprocessableEntity, body)
+}
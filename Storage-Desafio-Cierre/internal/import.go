@@ -0,0 +1,71 @@
+package internal
+
+import "errors"
+
+// ErrImportRowMalformed is the error of a row of an import that can not be decoded.
+var ErrImportRowMalformed = errors.New("malformed row")
+
+// ImportMode is how an import handles the rows that fail.
+type ImportMode string
+
+const (
+	// ImportAtomic saves every row or none of them.
+	ImportAtomic ImportMode = "atomic"
+	// ImportPartial saves the rows that do not fail.
+	ImportPartial ImportMode = "partial"
+)
+
+// ImportOptions are the options of an import.
+type ImportOptions struct {
+	// Mode is how the rows that fail are handled.
+	Mode ImportMode
+	// DryRun checks the rows as the import would without saving any of them.
+	DryRun bool
+}
+
+// Validate checks the rules of the import options.
+func (o ImportOptions) Validate() error {
+	return Validate(
+		Rule{"mode", o.Mode == ImportAtomic || o.Mode == ImportPartial, "must be atomic or partial"},
+	)
+}
+
+// ImportRow is a row of an import: the entity decoded from it or the error decoding it.
+type ImportRow[T any] struct {
+	// Row is the number of the row, 1 for the first one.
+	Row int
+	// Entity is the entity of the row, its id is set once it is saved.
+	Entity T
+	// Err is the error decoding, validating or saving the row.
+	Err error
+}
+
+// ImportRowError is the error of a row of an import.
+type ImportRowError struct {
+	// Row is the number of the row, 1 for the first one.
+	Row int
+	// Err is the error of the row.
+	Err error
+}
+
+// ImportResult is the summary of an import.
+type ImportResult struct {
+	// DryRun is true if nothing was saved because the import was a dry run.
+	DryRun bool
+	// Inserted is the number of rows saved, or that would be saved in a dry run.
+	Inserted int
+	// Skipped is the number of valid rows not saved because another row of an atomic import failed.
+	Skipped int
+	// Failed is the number of rows that failed.
+	Failed int
+	// Errors are the errors of the rows that failed, in the order of the rows.
+	Errors []ImportRowError
+}
+
+// IsRowError reports whether err is a rule an entity does not satisfy, as opposed to an error of the storage
+// that fails the whole import.
+func IsRowError(err error) bool {
+	return errors.Is(err, ErrImportRowMalformed) || errors.Is(err, ErrValidation) ||
+		errors.Is(err, ErrConflict) || errors.Is(err, ErrInvalidReference) ||
+		errors.Is(err, ErrMoneyInvalid) || errors.Is(err, ErrCustomerConditionInvalid)
+}
@@ -1,19 +1,111 @@
 package internal
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDatetimeInvalid is the error returned when an invoice datetime can not be parsed.
+var ErrDatetimeInvalid = errors.New("invalid datetime")
+
+// DatetimeLayouts are the layouts accepted by ParseDatetime, in the order they are tried.
+// Layouts without an offset are read in the store time zone.
+var DatetimeLayouts = []string{time.RFC3339, time.DateTime, time.DateOnly}
+
+// ParseDatetime parses an invoice datetime as RFC 3339, e.g. 2024-01-02T10:00:00-03:00,
+// as a datetime or as a date, e.g. 2024-01-02, and returns it in the store time zone loc.
+// An empty string is the zero time.
+func ParseDatetime(s string, loc *time.Location) (t time.Time, err error) {
+	if s == "" {
+		return
+	}
+	for _, layout := range DatetimeLayouts {
+		t, err = time.ParseInLocation(layout, s, loc)
+		if err == nil {
+			t = t.In(loc)
+			return
+		}
+	}
+	err = fmt.Errorf("%w: %q, expected RFC 3339 or YYYY-MM-DD", ErrDatetimeInvalid, s)
+	return
+}
+
 // InvoiceAttributes is the struct that represents the attributes of an invoice.
 type InvoiceAttributes struct {
 	// Datetime is the datetime of the invoice.
-	Datetime string
+	Datetime time.Time
 	// Total is the total of the invoice.
-	Total float64
+	Total Money
 	// CustomerId is the customer id of the invoice.
 	CustomerId int
 }
 
+// Validate checks the rules of the invoice attributes.
+func (i InvoiceAttributes) Validate() error {
+	return Validate(
+		Rule{"datetime", !i.Datetime.IsZero(), "is required"},
+		Rule{"total", i.Total >= 0, "can not be negative"},
+		Rule{"customer_id", i.CustomerId > 0, "is required"},
+	)
+}
+
 // Invoice is the struct that represents an invoice.
 type Invoice struct {
 	// Id is the id of the invoice.
 	Id int
 	// InvoiceAttributes is the attributes of the invoice.
 	InvoiceAttributes
-}
\ No newline at end of file
+}
+
+// InvoiceSortFields are the fields a list of invoices can be sorted by.
+var InvoiceSortFields = []string{"id", "datetime", "total", "customer_id"}
+
+// SortValue returns the value of a sort field of the invoice, or nil if it is not a sort field.
+func (i Invoice) SortValue(field string) (value any) {
+	switch field {
+	case "id":
+		value = i.Id
+	case "datetime":
+		value = i.Datetime
+	case "total":
+		value = i.Total
+	case "customer_id":
+		value = i.CustomerId
+	}
+	return
+}
+
+// InvoiceLine is a line of an invoice document: a sale of the invoice with its product.
+type InvoiceLine struct {
+	// SaleId is the id of the sale of the line.
+	SaleId int
+	// ProductId is the id of the product sold.
+	ProductId int
+	// Description is the description of the product sold.
+	Description string
+	// Quantity is the quantity sold.
+	Quantity int
+	// UnitPrice is the price the product was sold at.
+	UnitPrice Money
+	// Total is the unit price times the quantity.
+	Total Money
+}
+
+// InvoiceDocument is an invoice with its customer and lines, as it is printed.
+type InvoiceDocument struct {
+	// Invoice is the invoice of the document.
+	Invoice
+	// Customer is the customer of the invoice.
+	Customer Customer
+	// Lines are the lines of the invoice in the order of its sales.
+	Lines []InvoiceLine
+}
+
+// LinesTotal returns the sum of the totals of the lines.
+func (d InvoiceDocument) LinesTotal() (t Money) {
+	for _, l := range d.Lines {
+		t += l.Total
+	}
+	return
+}
@@ -1,10 +1,38 @@
 package internal
 
+import (
+	"context"
+	"fmt"
+)
+
+var (
+	// ErrInvoiceNotFound is returned when an invoice does not exist.
+	ErrInvoiceNotFound = fmt.Errorf("invoice %w", ErrNotFound)
+	// ErrInvoiceInvalidReference is returned when an invoice references a customer that does not exist.
+	ErrInvoiceInvalidReference = fmt.Errorf("%w: invoice references a customer that does not exist", ErrInvalidReference)
+)
+
 // RepositoryInvoice is the interface that wraps the basic methods that an invoice repository should implement.
 type RepositoryInvoice interface {
-	// FindAll returns all invoices
-	FindAll() (i []Invoice, err error)
+	// FindAll returns the invoices of a page
+	FindAll(ctx context.Context, page Page) (i []Invoice, err error)
+	// Count returns the number of invoices
+	Count(ctx context.Context) (n int, err error)
+	// FindById returns the invoice with the given id
+	FindById(ctx context.Context, id int) (i Invoice, err error)
+	// FindDocument returns the invoice with the given id with its customer and the lines of its sales
+	FindDocument(ctx context.Context, id int) (d InvoiceDocument, err error)
 	// Save saves an invoice
-	Save(i *Invoice) (err error)
-	UpdateTotal() (err error)
-}
\ No newline at end of file
+	Save(ctx context.Context, i *Invoice) (err error)
+	// SaveWithSales saves an invoice and its sales in a single transaction.
+	// The total of the invoice is computed from the sales and the prices of their products.
+	SaveWithSales(ctx context.Context, i *Invoice, s []Sale) (err error)
+	// Update updates the datetime and customer of an invoice and sets i to its total, which only changes with its sales
+	Update(ctx context.Context, i *Invoice) (err error)
+	// Delete deletes an invoice, cascading to its sales
+	Delete(ctx context.Context, id int) (err error)
+	// UpdateTotal sets the total of every invoice from its sales
+	UpdateTotal(ctx context.Context) (err error)
+	// UpdateTotalById sets the total of an invoice from its sales and returns it
+	UpdateTotalById(ctx context.Context, id int) (i Invoice, err error)
+}
@@ -1,10 +1,28 @@
 package internal
 
+import "context"
+
 // ServiceInvoice is the interface that wraps the basic methods that an invoice service should implement.
 type ServiceInvoice interface {
-	// FindAll returns all invoices
-	FindAll() (i []Invoice, err error)
-	// Save saves an invoice
-	Save(i *Invoice) (err error)
-	UpdateTotal() (err error)
-}
\ No newline at end of file
+	// FindAll returns the invoices of a page
+	FindAll(ctx context.Context, page Page) (i []Invoice, err error)
+	// Count returns the number of invoices
+	Count(ctx context.Context) (n int, err error)
+	// FindById returns an invoice by id
+	FindById(ctx context.Context, id int) (i Invoice, err error)
+	// FindDocument returns an invoice by id with its customer and lines, as it is printed
+	FindDocument(ctx context.Context, id int) (d InvoiceDocument, err error)
+	// Save saves an invoice with a zero total, it is computed from the sales added to it
+	Save(ctx context.Context, i *Invoice) (err error)
+	// Checkout validates and saves an invoice with its sales atomically,
+	// computing the total from the prices of the products
+	Checkout(ctx context.Context, i *Invoice, s []Sale) (err error)
+	// Update updates the datetime and customer of an invoice, keeping the total computed from its sales
+	Update(ctx context.Context, i *Invoice) (err error)
+	// Delete deletes an invoice and its sales
+	Delete(ctx context.Context, id int) (err error)
+	// UpdateTotal sets the total of every invoice from its sales
+	UpdateTotal(ctx context.Context) (err error)
+	// UpdateTotalById sets the total of an invoice from its sales and returns it
+	UpdateTotalById(ctx context.Context, id int) (i Invoice, err error)
+}
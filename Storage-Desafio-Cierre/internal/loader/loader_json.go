@@ -0,0 +1,197 @@
+package loader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"app/internal"
+)
+
+// CustomerJSON is a customer as stored in customers.json
+type CustomerJSON struct {
+	Id        int                        `json:"id"`
+	FirstName string                     `json:"first_name"`
+	LastName  string                     `json:"last_name"`
+	Condition internal.CustomerCondition `json:"condition"`
+}
+
+// ProductJSON is a product as stored in products.json
+type ProductJSON struct {
+	Id          int            `json:"id"`
+	Description string         `json:"description"`
+	Price       internal.Money `json:"price"`
+	// Stock is the stock left once the sales of the fixtures are saved, zero if missing.
+	Stock int `json:"stock"`
+}
+
+// InvoiceJSON is an invoice as stored in invoices.json
+type InvoiceJSON struct {
+	Id         int    `json:"id"`
+	Datetime   string `json:"datetime"`
+	CustomerId int    `json:"customer_id"`
+}
+
+// SaleJSON is a sale as stored in sales.json
+type SaleJSON struct {
+	Id        int `json:"id"`
+	ProductId int `json:"product_id"`
+	InvoiceId int `json:"invoice_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// NewLoaderJSON creates a new LoaderJSON.
+// Invoice datetimes without an offset, e.g. 2022-05-15, are read in the store time zone loc.
+func NewLoaderJSON(dir string, loc *time.Location, rpCustomer internal.RepositoryCustomer, rpProduct internal.RepositoryProduct, rpInvoice internal.RepositoryInvoice, rpSale internal.RepositorySale) *LoaderJSON {
+	return &LoaderJSON{
+		dir:        dir,
+		loc:        loc,
+		rpCustomer: rpCustomer,
+		rpProduct:  rpProduct,
+		rpInvoice:  rpInvoice,
+		rpSale:     rpSale,
+	}
+}
+
+// LoaderJSON loads the json fixtures of a directory through the repositories.
+type LoaderJSON struct {
+	// dir is the directory that contains the json files.
+	dir string
+	// loc is the store time zone of the invoice datetimes.
+	loc *time.Location
+	// rpCustomer is the repository for customer entity.
+	rpCustomer internal.RepositoryCustomer
+	// rpProduct is the repository for product entity.
+	rpProduct internal.RepositoryProduct
+	// rpInvoice is the repository for invoice entity.
+	rpInvoice internal.RepositoryInvoice
+	// rpSale is the repository for sale entity.
+	rpSale internal.RepositorySale
+}
+
+// Load saves customers, products, invoices and sales in foreign key order,
+// keeping the fixture ids, and then updates the invoices total.
+// Products are saved with their stock plus the quantity of their sales,
+// so once the sales take it they are left with the stock of the fixtures.
+// Load does not handle transactions, the repositories should share one if needed.
+func (l *LoaderJSON) Load(ctx context.Context) (err error) {
+	// customers
+	var customers []CustomerJSON
+	err = l.read("customers.json", &customers)
+	if err != nil {
+		return
+	}
+	for _, v := range customers {
+		c := internal.Customer{
+			Id: v.Id,
+			CustomerAttributes: internal.CustomerAttributes{
+				FirstName: v.FirstName,
+				LastName:  v.LastName,
+				Condition: v.Condition,
+			},
+		}
+		err = l.rpCustomer.Save(ctx, &c)
+		if err != nil {
+			return fmt.Errorf("saving customer %d: %w", v.Id, err)
+		}
+	}
+
+	// sales: read ahead, as the products need the stock their sales take
+	var sales []SaleJSON
+	err = l.read("sales.json", &sales)
+	if err != nil {
+		return
+	}
+	sold := make(map[int]int)
+	for _, v := range sales {
+		sold[v.ProductId] += v.Quantity
+	}
+
+	// products
+	var products []ProductJSON
+	err = l.read("products.json", &products)
+	if err != nil {
+		return
+	}
+	for _, v := range products {
+		p := internal.Product{
+			Id: v.Id,
+			ProductAttributes: internal.ProductAttributes{
+				Description: v.Description,
+				Price:       v.Price,
+				Stock:       v.Stock + sold[v.Id],
+			},
+		}
+		err = l.rpProduct.Save(ctx, &p)
+		if err != nil {
+			return fmt.Errorf("saving product %d: %w", v.Id, err)
+		}
+	}
+
+	// invoices
+	var invoices []InvoiceJSON
+	err = l.read("invoices.json", &invoices)
+	if err != nil {
+		return
+	}
+	for _, v := range invoices {
+		datetime, errParse := internal.ParseDatetime(v.Datetime, l.loc)
+		if errParse != nil {
+			return fmt.Errorf("parsing invoice %d: %w", v.Id, errParse)
+		}
+		i := internal.Invoice{
+			Id: v.Id,
+			InvoiceAttributes: internal.InvoiceAttributes{
+				Datetime:   datetime,
+				CustomerId: v.CustomerId,
+			},
+		}
+		err = l.rpInvoice.Save(ctx, &i)
+		if err != nil {
+			return fmt.Errorf("saving invoice %d: %w", v.Id, err)
+		}
+	}
+
+	// sales
+	for _, v := range sales {
+		s := internal.Sale{
+			Id: v.Id,
+			SaleAttributes: internal.SaleAttributes{
+				Quantity:  v.Quantity,
+				ProductId: v.ProductId,
+				InvoiceId: v.InvoiceId,
+			},
+		}
+		err = l.rpSale.Save(ctx, &s)
+		if err != nil {
+			return fmt.Errorf("saving sale %d: %w", v.Id, err)
+		}
+	}
+
+	// totals
+	err = l.rpInvoice.UpdateTotal(ctx)
+	if err != nil {
+		return fmt.Errorf("updating invoices total: %w", err)
+	}
+
+	return
+}
+
+// read decodes the json file name of the loader directory into ptr.
+func (l *LoaderJSON) read(name string, ptr any) (err error) {
+	f, err := os.Open(filepath.Join(l.dir, name))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	err = json.NewDecoder(f).Decode(ptr)
+	if err != nil {
+		return fmt.Errorf("decoding %s: %w", name, err)
+	}
+
+	return
+}
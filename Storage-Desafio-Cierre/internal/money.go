@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrMoneyInvalid is the error returned when an amount of money can not be parsed.
+var ErrMoneyInvalid = errors.New("invalid money amount")
+
+// Money is an amount of money in cents, so prices and totals add up exactly.
+// It is stored as DECIMAL(12,2) and encoded in JSON as a number with two decimals.
+type Money int64
+
+// NewMoney returns the money of an amount in units, e.g. 12.5, rounded to cents.
+func NewMoney(units float64) (m Money) {
+	m, _ = ParseMoney(strconv.FormatFloat(units, 'f', -1, 64))
+	return
+}
+
+// ParseMoney parses a decimal amount in units, e.g. "12.50" or "-3.005".
+// Digits past the cents are rounded half away from zero, it is the only place amounts are rounded.
+func ParseMoney(s string) (m Money, err error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || strings.Contains(s, "/") {
+		err = fmt.Errorf("%w: %q", ErrMoneyInvalid, s)
+		return
+	}
+
+	// cents = round(|r| * 100) with the sign of r
+	r.Mul(r, big.NewRat(100, 1))
+	cents, rem := new(big.Int).QuoRem(new(big.Int).Abs(r.Num()), r.Denom(), new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(r.Denom()) >= 0 {
+		cents.Add(cents, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		cents.Neg(cents)
+	}
+	if !cents.IsInt64() {
+		err = fmt.Errorf("%w: %q is out of range", ErrMoneyInvalid, s)
+		return
+	}
+
+	m = Money(cents.Int64())
+	return
+}
+
+// Mul returns the money multiplied by a quantity.
+func (m Money) Mul(quantity int) Money {
+	return m * Money(quantity)
+}
+
+// Float64 returns the money in units, it is meant for ratios and not for arithmetic.
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// String returns the money in units with two decimals, e.g. 12.50.
+func (m Money) String() string {
+	sign, cents := "", int64(m)
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// MarshalJSON encodes the money as a number with two decimals.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON decodes the money from a number, rounded to cents.
+func (m *Money) UnmarshalJSON(b []byte) (err error) {
+	s := string(b)
+	if s == "null" {
+		return
+	}
+	*m, err = ParseMoney(s)
+	return
+}
+
+// Scan implements sql.Scanner for DECIMAL columns and aggregates.
+func (m *Money) Scan(src any) (err error) {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case []byte:
+		*m, err = ParseMoney(string(v))
+	case string:
+		*m, err = ParseMoney(v)
+	case int64:
+		*m = Money(v * 100)
+	case float64:
+		*m = NewMoney(v)
+	default:
+		err = fmt.Errorf("%w: unsupported type %T", ErrMoneyInvalid, src)
+	}
+	return
+}
+
+// Value implements driver.Valuer, the money is sent as a decimal string.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,52 @@
+package internal_test
+
+import (
+	"app/internal"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	t.Run("should parse amounts and round half away from zero", func(t *testing.T) {
+		cases := map[string]internal.Money{
+			"12":     1200,
+			"12.5":   1250,
+			"0.1":    10,
+			"1.005":  101,
+			"1.0049": 100,
+			"-1.005": -101,
+			"1e2":    10000,
+		}
+		for s, expected := range cases {
+			m, err := internal.ParseMoney(s)
+
+			assert.NoError(t, err, s)
+			assert.Equal(t, expected, m, s)
+		}
+	})
+
+	t.Run("should fail on invalid amounts", func(t *testing.T) {
+		for _, s := range []string{"", "abc", "1/3"} {
+			_, err := internal.ParseMoney(s)
+
+			assert.ErrorIs(t, err, internal.ErrMoneyInvalid, s)
+		}
+	})
+}
+
+func TestMoney_JSON(t *testing.T) {
+	t.Run("should encode with two decimals and add up exactly", func(t *testing.T) {
+		var p struct{ Price internal.Money }
+		require.NoError(t, json.Unmarshal([]byte(`{"Price": 0.1}`), &p))
+
+		total := p.Price.Mul(3)
+		b, err := json.Marshal(map[string]internal.Money{"total": total, "negative": -5})
+
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"total": 0.30, "negative": -0.05}`, string(b))
+		assert.Equal(t, "0.30", total.String())
+	})
+}
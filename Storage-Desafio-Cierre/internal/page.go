@@ -0,0 +1,39 @@
+package internal
+
+import "errors"
+
+var (
+	// ErrPageInvalid is returned when the pagination or sorting of a list is not valid.
+	ErrPageInvalid = errors.New("invalid page")
+)
+
+// Page is the pagination and sorting of a list.
+// The zero value returns every item sorted by id.
+type Page struct {
+	// Limit is the maximum number of items, zero means no limit.
+	Limit int
+	// Offset is the number of items to skip, it is ignored if After is set.
+	Offset int
+	// Sort is the field to sort by, empty means id.
+	Sort string
+	// Desc sorts in descending order.
+	Desc bool
+	// After is the cursor of the last item of the previous page.
+	After *Cursor
+}
+
+// SortField returns the field to sort by, defaulting to id.
+func (p Page) SortField() string {
+	if p.Sort == "" {
+		return "id"
+	}
+	return p.Sort
+}
+
+// Cursor is the position of an item in a sorted list.
+type Cursor struct {
+	// Value is the value of the sort field of the item.
+	Value any
+	// Id is the id of the item, it breaks ties between equal values.
+	Id int
+}
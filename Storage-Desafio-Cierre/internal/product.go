@@ -1,11 +1,29 @@
 package internal
 
+import (
+	"strings"
+	"unicode/utf8"
+)
+
 // ProductAttributes is the struct that represents the attributes of a product.
 type ProductAttributes struct {
 	// Description is the description of the product.
 	Description string
 	// Price is the price of the product.
-	Price float64
+	Price Money
+	// Stock is the quantity of the product available to sell.
+	// It only changes through sales and stock movements, see StockMovement.
+	Stock int
+}
+
+// Validate checks the rules of the product attributes.
+func (p ProductAttributes) Validate() error {
+	return Validate(
+		Rule{"description", strings.TrimSpace(p.Description) != "", "is required"},
+		Rule{"description", utf8.RuneCountInString(p.Description) <= 100, "must be at most 100 characters"},
+		Rule{"price", p.Price >= 0, "can not be negative"},
+		Rule{"stock", p.Stock >= 0, "can not be negative"},
+	)
 }
 
 // Product is the struct that represents a product.
@@ -16,7 +34,32 @@ type Product struct {
 	ProductAttributes
 }
 
+// ProductSortFields are the fields a list of products can be sorted by.
+var ProductSortFields = []string{"id", "description", "price", "stock"}
+
+// SortValue returns the value of a sort field of the product, or nil if it is not a sort field.
+func (p Product) SortValue(field string) (value any) {
+	switch field {
+	case "id":
+		value = p.Id
+	case "description":
+		value = p.Description
+	case "price":
+		value = p.Price
+	case "stock":
+		value = p.Stock
+	}
+	return
+}
+
+// ProductAmount is a product of the top products report.
 type ProductAmount struct {
+	// Description is the description of the product.
 	Description string
-	Total       float64
+	// Units is the quantity sold.
+	Units int
+	// Revenue is the quantity sold * the price the product was sold at.
+	Revenue Money
+	// Share is the fraction of the revenue of every product sold in the period that is of the product, from 0 to 1.
+	Share float64
 }
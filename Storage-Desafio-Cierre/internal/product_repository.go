@@ -1,10 +1,49 @@
 package internal
 
+import (
+	"context"
+	"fmt"
+)
+
+var (
+	// ErrProductNotFound is returned when a product does not exist.
+	ErrProductNotFound = fmt.Errorf("product %w", ErrNotFound)
+	// ErrProductHasSales is returned when deleting a product that has sales.
+	ErrProductHasSales = fmt.Errorf("%w: product has sales", ErrConflict)
+	// ErrInsufficientStock is returned when a sale or a stock movement takes more than the stock of a product.
+	ErrInsufficientStock = fmt.Errorf("%w: insufficient stock", ErrConflict)
+)
+
 // RepositoryProduct is the interface that wraps the basic methods that a product repository must have.
 type RepositoryProduct interface {
-	// FindAll returns all products saved in the database.
-	FindAll() (p []Product, err error)
-	// Save saves a product into the database.
-	Save(p *Product) (err error)
-	FindTopProductsByAmount(limit int) (p []ProductAmount, err error)
-}
\ No newline at end of file
+	// FindAll returns the products of a page
+	FindAll(ctx context.Context, page Page) (p []Product, err error)
+	// Count returns the number of products
+	Count(ctx context.Context) (n int, err error)
+	// FindById returns the product with the given id.
+	FindById(ctx context.Context, id int) (p Product, err error)
+	// Save saves a product into the database, recording its initial stock as a stock movement.
+	Save(ctx context.Context, p *Product) (err error)
+	// SaveAll saves the products in one transaction, in order, errs has the error of each one, nil if it was saved.
+	// The transaction is only committed if commit is true and every product was saved.
+	SaveAll(ctx context.Context, p []Product, commit bool) (errs []error, err error)
+	// Update updates a product in the database, except its stock which is set to the current one.
+	Update(ctx context.Context, p *Product) (err error)
+	// Delete deletes a product without sales, it returns ErrProductHasSales if it has any
+	// so they are not removed by the cascade.
+	Delete(ctx context.Context, id int) (err error)
+	// FindTopProductsByAmount returns the products sold in the invoices of the filter of the query ranked as the query sets,
+	// up to its limit, with their share of the revenue of every product sold in them.
+	FindTopProductsByAmount(ctx context.Context, q TopProductsQuery) (p []ProductAmount, err error)
+	// FindBasketStats returns the counts of the invoices of the filter with sales of each product and pair of products.
+	FindBasketStats(ctx context.Context, f ReportFilter) (s BasketStats, err error)
+	// AdjustStock adds the quantity of the movement to the stock of its product and records the movement,
+	// setting its id, datetime and balance. It returns ErrInsufficientStock if the stock would be negative.
+	AdjustStock(ctx context.Context, m *StockMovement) (err error)
+	// FindStockMovements returns the stock movements of a product of a page
+	FindStockMovements(ctx context.Context, productId int, page Page) (m []StockMovement, err error)
+	// CountStockMovements returns the number of stock movements of a product
+	CountStockMovements(ctx context.Context, productId int) (n int, err error)
+	// FindLowStock returns the products with a stock up to threshold, ordered by stock and id
+	FindLowStock(ctx context.Context, threshold int) (p []Product, err error)
+}
@@ -1,10 +1,34 @@
 package internal
 
+import "context"
+
 // ServiceProduct is the interface that wraps the basic Product methods.
 type ServiceProduct interface {
-	// FindAll returns all products.
-	FindAll() (p []Product, err error)
+	// FindAll returns the products of a page
+	FindAll(ctx context.Context, page Page) (p []Product, err error)
+	// Count returns the number of products
+	Count(ctx context.Context) (n int, err error)
+	// FindById returns a product by id.
+	FindById(ctx context.Context, id int) (p Product, err error)
 	// Save saves a product.
-	Save(p *Product) (err error)
-	FindTopProductsByAmount(limit int) (p []ProductAmount, err error)
+	Save(ctx context.Context, p *Product) (err error)
+	// Import saves the products of the rows of an import, see ImportOptions
+	Import(ctx context.Context, rows []ImportRow[Product], o ImportOptions) (r ImportResult, err error)
+	// Update updates a product, except its stock.
+	// A price change does not modify the total of existing invoices.
+	Update(ctx context.Context, p *Product) (err error)
+	// Delete deletes a product, refusing if it has sales.
+	Delete(ctx context.Context, id int) (err error)
+	// FindTopProductsByAmount returns the products ranked by the quantity sold or the revenue, see TopProductsQuery
+	FindTopProductsByAmount(ctx context.Context, q TopProductsQuery) (p []ProductAmount, err error)
+	// FindAssociations returns the products frequently bought together, see BasketQuery
+	FindAssociations(ctx context.Context, q BasketQuery) (a []ProductAssociation, err error)
+	// AdjustStock adds the quantity of the movement to the stock of its product
+	AdjustStock(ctx context.Context, m *StockMovement) (err error)
+	// FindStockMovements returns the stock movements of a product of a page
+	FindStockMovements(ctx context.Context, productId int, page Page) (m []StockMovement, err error)
+	// CountStockMovements returns the number of stock movements of a product
+	CountStockMovements(ctx context.Context, productId int) (n int, err error)
+	// FindLowStock returns the products with a stock up to threshold
+	FindLowStock(ctx context.Context, threshold int) (p []Product, err error)
 }
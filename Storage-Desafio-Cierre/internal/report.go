@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReportLimitMax is the maximum number of rows of a ranking report.
+const ReportLimitMax = 100
+
+// ReportFilter is the filter of the reports.
+type ReportFilter struct {
+	// Limit is the maximum number of rows of a ranking report, e.g. the top customers.
+	Limit int
+	// From is the start of the period of the invoices, inclusive, no start if zero.
+	From time.Time
+	// To is the end of the period of the invoices, exclusive, no end if zero.
+	To time.Time
+	// Condition is the condition of the customers of the invoices, any if nil.
+	Condition *CustomerCondition
+	// ConditionAt is the time the condition of the customers is taken at, the current one if zero.
+	ConditionAt time.Time
+}
+
+// Validate checks the rules of the report filter.
+func (f ReportFilter) Validate() error {
+	return Validate(
+		Rule{"limit", f.Limit >= 1 && f.Limit <= ReportLimitMax, fmt.Sprintf("must be between 1 and %d", ReportLimitMax)},
+		Rule{"to", f.From.IsZero() || f.To.IsZero() || f.To.After(f.From), "must be after from"},
+		Rule{"condition", f.Condition == nil || f.Condition.Valid(), "must be active or inactive"},
+	)
+}
+
+// RevenueInterval is the size of the buckets of the revenue report.
+type RevenueInterval string
+
+const (
+	// RevenueDay buckets start at midnight.
+	RevenueDay RevenueInterval = "day"
+	// RevenueWeek buckets start on Monday at midnight.
+	RevenueWeek RevenueInterval = "week"
+	// RevenueMonth buckets start on the first day of the month at midnight.
+	RevenueMonth RevenueInterval = "month"
+)
+
+// RevenueBucketsMax is the maximum number of buckets of a revenue report.
+const RevenueBucketsMax = 1000
+
+// Valid reports whether the interval is day, week or month.
+func (v RevenueInterval) Valid() bool {
+	return v == RevenueDay || v == RevenueWeek || v == RevenueMonth
+}
+
+// Start returns the start of the bucket of t, in the location of t.
+func (v RevenueInterval) Start(t time.Time) time.Time {
+	y, m, d := t.Date()
+	switch v {
+	case RevenueWeek:
+		// weeks start on Monday
+		d -= (int(t.Weekday()) + 6) % 7
+	case RevenueMonth:
+		d = 1
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// Next returns the start of the bucket after the one starting at t.
+func (v RevenueInterval) Next(t time.Time) time.Time {
+	switch v {
+	case RevenueWeek:
+		return t.AddDate(0, 0, 7)
+	case RevenueMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// RevenueQuery is the query of the revenue report.
+type RevenueQuery struct {
+	// ReportFilter is the filter of the invoices, its period is required and its limit is not used.
+	ReportFilter
+	// Interval is the size of the buckets.
+	Interval RevenueInterval
+	// ByCondition splits each bucket by the condition of the customers.
+	ByCondition bool
+}
+
+// Validate checks the rules of the revenue query.
+func (q RevenueQuery) Validate() error {
+	period := !q.From.IsZero() && q.To.After(q.From)
+	return Validate(
+		Rule{"from", !q.From.IsZero(), "is required"},
+		Rule{"to", !q.To.IsZero(), "is required"},
+		Rule{"to", q.To.After(q.From), "must be after from"},
+		Rule{"condition", q.Condition == nil || q.Condition.Valid(), "must be active or inactive"},
+		Rule{"interval", q.Interval.Valid(), "must be day, week or month"},
+		Rule{"interval", !period || len(q.Starts()) <= RevenueBucketsMax, fmt.Sprintf("must give at most %d buckets in the period", RevenueBucketsMax)},
+	)
+}
+
+// Starts returns the start of every bucket of the period, the first one is the bucket of From.
+func (q RevenueQuery) Starts() (s []time.Time) {
+	for t := q.Interval.Start(q.From); t.Before(q.To) && len(s) <= RevenueBucketsMax; t = q.Interval.Next(t) {
+		s = append(s, t)
+	}
+	return
+}
+
+// RevenueBucket is the revenue of the invoices of a bucket of the revenue report.
+type RevenueBucket struct {
+	// Start is the start of the bucket, in the store time zone.
+	Start time.Time
+	// Condition is the condition of the customers of the invoices, nil if the report is not split by condition.
+	Condition *CustomerCondition
+	// Invoices is the number of invoices.
+	Invoices int
+	// Units is the quantity sold in the sales of the invoices.
+	Units int
+	// Revenue is the sum of the invoices total.
+	Revenue Money
+}
+
+// ProductRanking is the value the top products report ranks the products by.
+type ProductRanking string
+
+const (
+	// RankByUnits ranks the products by the quantity sold.
+	RankByUnits ProductRanking = "units"
+	// RankByRevenue ranks the products by the quantity sold * the price they were sold at.
+	RankByRevenue ProductRanking = "revenue"
+)
+
+// Valid reports whether the ranking is units or revenue.
+func (v ProductRanking) Valid() bool {
+	return v == RankByUnits || v == RankByRevenue
+}
+
+// TopProductsQuery is the query of the top products report.
+type TopProductsQuery struct {
+	// ReportFilter is the filter of the invoices of the sales.
+	ReportFilter
+	// RankBy is the value the products are ranked by, ties are ranked by the other one and then by id.
+	RankBy ProductRanking
+}
+
+// Validate checks the rules of the top products query.
+func (q TopProductsQuery) Validate() error {
+	return JoinValidation(
+		q.ReportFilter.Validate(),
+		Validate(Rule{"rank_by", q.RankBy.Valid(), "must be units or revenue"}),
+	)
+}
@@ -0,0 +1,12 @@
+package internal
+
+import "context"
+
+// RepositoryReport is the interface that wraps the methods of the reports that aggregate several entities.
+type RepositoryReport interface {
+	// FindRevenue returns the buckets of the query with invoices, ordered by start and condition.
+	// Buckets without invoices are not returned.
+	FindRevenue(ctx context.Context, q RevenueQuery) (b []RevenueBucket, err error)
+	// FindCustomerActivity returns the activity of the customers with invoices of the filter, ordered by id.
+	FindCustomerActivity(ctx context.Context, f ReportFilter) (a []CustomerActivity, err error)
+}
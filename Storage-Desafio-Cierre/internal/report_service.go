@@ -0,0 +1,13 @@
+package internal
+
+import "context"
+
+// ServiceReport is the interface that wraps the methods of the reports that aggregate several entities.
+type ServiceReport interface {
+	// FindRevenue returns the revenue of every bucket of the period of the query, zero for the ones
+	// without invoices, and of every condition of each bucket if the query is split by condition.
+	FindRevenue(ctx context.Context, q RevenueQuery) (b []RevenueBucket, err error)
+	// FindRFM returns the recency, frequency and monetary scores and segment of the customers with invoices
+	// of the query, and the number of customers and revenue of every segment.
+	FindRFM(ctx context.Context, q RFMQuery) (r RFMReport, err error)
+}
@@ -0,0 +1,33 @@
+package internal_test
+
+import (
+	"app/internal"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevenueInterval_Start(t *testing.T) {
+	t.Run("should start the buckets at midnight of the day, monday and first day of the month", func(t *testing.T) {
+		// sunday
+		d := time.Date(2024, 3, 3, 23, 30, 0, 0, time.UTC)
+
+		assert.Equal(t, time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), internal.RevenueDay.Start(d))
+		assert.Equal(t, time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC), internal.RevenueWeek.Start(d))
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), internal.RevenueMonth.Start(d))
+	})
+}
+
+func TestRevenueQuery_Validate(t *testing.T) {
+	t.Run("should limit the number of buckets", func(t *testing.T) {
+		q := internal.RevenueQuery{
+			ReportFilter: internal.ReportFilter{From: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			Interval:     internal.RevenueDay,
+		}
+
+		assert.ErrorIs(t, q.Validate(), internal.ErrValidation)
+		q.Interval = internal.RevenueWeek
+		assert.NoError(t, q.Validate())
+	})
+}
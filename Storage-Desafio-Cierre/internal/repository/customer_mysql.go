@@ -1,26 +1,37 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
 	"app/internal"
 )
 
 // NewCustomersMySQL creates new mysql repository for customer entity.
-func NewCustomersMySQL(db *sql.DB) *CustomersMySQL {
+// The db can be a *sql.DB or a *sql.Tx.
+func NewCustomersMySQL(db Querier) *CustomersMySQL {
 	return &CustomersMySQL{db}
 }
 
 // CustomersMySQL is the MySQL repository implementation for customer entity.
 type CustomersMySQL struct {
-	// db is the database connection.
-	db *sql.DB
+	// db is the database connection or transaction.
+	db Querier
 }
 
-// FindAll returns all customers from the database.
-func (r *CustomersMySQL) FindAll() (c []internal.Customer, err error) {
+// FindAll returns the customers of a page from the database.
+func (r *CustomersMySQL) FindAll(ctx context.Context, page internal.Page) (c []internal.Customer, err error) {
+	// build the query
+	clause, args, err := pageClause(page, internal.CustomerSortFields)
+	if err != nil {
+		return
+	}
+
 	// execute the query
-	rows, err := r.db.Query("SELECT `id`, `first_name`, `last_name`, `condition` FROM customers")
+	rows, err := r.db.QueryContext(ctx, "SELECT `id`, `first_name`, `last_name`, `condition` FROM customers"+clause, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -45,12 +56,19 @@ func (r *CustomersMySQL) FindAll() (c []internal.Customer, err error) {
 	return
 }
 
+// Count returns the number of customers in the database.
+func (r *CustomersMySQL) Count(ctx context.Context) (n int, err error) {
+	n, err = count(ctx, r.db, "customers")
+	return
+}
+
 // Save saves the customer into the database.
-func (r *CustomersMySQL) Save(c *internal.Customer) (err error) {
+// A non-zero id is preserved, otherwise the database assigns one.
+func (r *CustomersMySQL) Save(ctx context.Context, c *internal.Customer) (err error) {
 	// execute the query
-	res, err := r.db.Exec(
-		"INSERT INTO customers (`first_name`, `last_name`, `condition`) VALUES (?, ?, ?)",
-		(*c).FirstName, (*c).LastName, (*c).Condition,
+	res, err := exec(ctx, r.db,
+		"INSERT INTO customers (`id`, `first_name`, `last_name`, `condition`) VALUES (?, ?, ?, ?)",
+		nullableId((*c).Id), (*c).FirstName, (*c).LastName, (*c).Condition,
 	)
 	if err != nil {
 		return err
@@ -68,14 +86,233 @@ func (r *CustomersMySQL) Save(c *internal.Customer) (err error) {
 	return
 }
 
-func (r *CustomersMySQL) FindTopActiveCustomersByAmountSpent(limit int) ([]internal.CustomerSpent, error) {
+// SaveAll saves the customers in one transaction, only committed if commit is true and every customer was saved.
+func (r *CustomersMySQL) SaveAll(ctx context.Context, c []internal.Customer, commit bool) (errs []error, err error) {
+	errs, err = saveAll(ctx, r.db, c, commit, func(tx Querier, c *internal.Customer) error {
+		return NewCustomersMySQL(tx).Save(ctx, c)
+	})
+	return
+}
+
+// FindById returns the customer with the given id from the database.
+func (r *CustomersMySQL) FindById(ctx context.Context, id int) (c internal.Customer, err error) {
+	// execute the query
+	row := r.db.QueryRowContext(ctx, "SELECT `id`, `first_name`, `last_name`, `condition` FROM customers WHERE `id` = ?", id)
+
+	// scan the row into the customer
+	err = row.Scan(&c.Id, &c.FirstName, &c.LastName, &c.Condition)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = internal.ErrCustomerNotFound
+		}
+		return
+	}
+
+	return
+}
+
+// Update updates the customer in the database and, in the same transaction,
+// records a change of its condition as made by the actor of ctx.
+func (r *CustomersMySQL) Update(ctx context.Context, c *internal.Customer) (err error) {
+	err = transaction(ctx, r.db, func(tx Querier) (err error) {
+		// get the current condition, locking the row
+		current, err := customerCondition(ctx, tx, (*c).Id)
+		if err != nil {
+			return
+		}
+
+		// execute the query
+		_, err = exec(ctx, tx,
+			"UPDATE customers SET `first_name` = ?, `last_name` = ?, `condition` = ? WHERE `id` = ?",
+			(*c).FirstName, (*c).LastName, (*c).Condition, (*c).Id,
+		)
+		if err != nil {
+			return
+		}
+
+		// condition history
+		if current == (*c).Condition {
+			return
+		}
+		err = insertConditionChange(ctx, tx, &internal.CustomerConditionChange{
+			CustomerId: (*c).Id,
+			Previous:   current,
+			Condition:  (*c).Condition,
+			ChangedBy:  internal.ActorFromContext(ctx),
+		})
+		return
+	})
+	return
+}
+
+// Delete deletes the customer from the database.
+// The foreign keys cascade the delete to its condition history and its invoices and their sales, whose quantities
+// are given back to the stock in the same transaction. Without cascade the customer is only deleted if it has no
+// invoices, in a single statement so an invoice saved meanwhile is not removed by the cascade.
+func (r *CustomersMySQL) Delete(ctx context.Context, id int, cascade bool) (err error) {
+	if !cascade {
+		err = r.deleteWithoutInvoices(ctx, id)
+		return
+	}
+
+	err = transaction(ctx, r.db, func(tx Querier) (err error) {
+		// stock
+		err = revertSalesStock(ctx, tx, "`invoice_id` IN (SELECT `id` FROM invoices WHERE `customer_id` = ?)", id)
+		if err != nil {
+			return
+		}
+
+		// execute the query
+		res, err := exec(ctx, tx, "DELETE FROM customers WHERE `id` = ?", id)
+		if err != nil {
+			return
+		}
+
+		// check the customer existed
+		n, err := res.RowsAffected()
+		if err != nil {
+			return
+		}
+		if n == 0 {
+			err = internal.ErrCustomerNotFound
+		}
+		return
+	})
+	return
+}
+
+// deleteWithoutInvoices deletes the customer from the database if it has no invoices,
+// or returns ErrCustomerHasInvoices if it has any.
+func (r *CustomersMySQL) deleteWithoutInvoices(ctx context.Context, id int) (err error) {
+	// execute the query
+	res, err := exec(ctx, r.db,
+		"DELETE FROM customers WHERE `id` = ? AND NOT EXISTS (SELECT 1 FROM invoices WHERE `customer_id` = ?)",
+		id, id,
+	)
+	if err != nil {
+		return
+	}
+
+	// check the customer existed and had no invoices
+	n, err := res.RowsAffected()
+	if err != nil || n > 0 {
+		return
+	}
+	ok, err := exists(ctx, r.db, "customers", id)
+	if err != nil {
+		return
+	}
+	err = internal.ErrCustomerNotFound
+	if ok {
+		err = internal.ErrCustomerHasInvoices
+	}
+	return
+}
+
+// ChangeCondition sets the condition of the customer in the database and, in the same transaction,
+// records the change as made by the actor of ctx.
+func (r *CustomersMySQL) ChangeCondition(ctx context.Context, ch *internal.CustomerConditionChange) (err error) {
+	err = transaction(ctx, r.db, func(tx Querier) (err error) {
+		// get the current condition, locking the row
+		current, err := customerCondition(ctx, tx, (*ch).CustomerId)
+		if err != nil {
+			return
+		}
+		if current == (*ch).Condition {
+			return fmt.Errorf("%w: customer %d is already %s", internal.ErrCustomerConditionUnchanged, (*ch).CustomerId, current)
+		}
+
+		// execute the query
+		_, err = exec(ctx, tx, "UPDATE customers SET `condition` = ? WHERE `id` = ?", (*ch).Condition, (*ch).CustomerId)
+		if err != nil {
+			return
+		}
+
+		// condition history
+		(*ch).Previous = current
+		(*ch).ChangedBy = internal.ActorFromContext(ctx)
+		err = insertConditionChange(ctx, tx, ch)
+		return
+	})
+	return
+}
+
+// FindConditionHistory returns the condition changes of the customer from the database ordered by datetime.
+func (r *CustomersMySQL) FindConditionHistory(ctx context.Context, id int) (h []internal.CustomerConditionChange, err error) {
+	// execute the query
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT `id`, `customer_id`, `previous`, `condition`, COALESCE(`changed_by`, ''), `datetime` "+
+			"FROM customer_condition_changes WHERE `customer_id` = ? ORDER BY `datetime`, `id`",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// iterate over the rows
+	for rows.Next() {
+		var ch internal.CustomerConditionChange
+		// scan the row into the change
+		err := rows.Scan(&ch.Id, &ch.CustomerId, &ch.Previous, &ch.Condition, &ch.ChangedBy, &ch.Datetime)
+		if err != nil {
+			return nil, err
+		}
+		// append the change to the slice
+		h = append(h, ch)
+	}
+	err = rows.Err()
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// customerCondition returns the condition of a customer, locking its row.
+func customerCondition(ctx context.Context, q Querier, id int) (c internal.CustomerCondition, err error) {
+	err = q.QueryRowContext(ctx, "SELECT `condition` FROM customers WHERE `id` = ? FOR UPDATE", id).Scan(&c)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = internal.ErrCustomerNotFound
+	}
+	return
+}
+
+// insertConditionChange inserts a condition change, setting its id and datetime.
+func insertConditionChange(ctx context.Context, q Querier, ch *internal.CustomerConditionChange) (err error) {
+	// execute the query
+	(*ch).Datetime = time.Now().Truncate(time.Second)
+	res, err := exec(ctx, q,
+		"INSERT INTO customer_condition_changes (`customer_id`, `previous`, `condition`, `changed_by`, `datetime`) VALUES (?, ?, ?, ?, ?)",
+		(*ch).CustomerId, (*ch).Previous, (*ch).Condition, nullableString((*ch).ChangedBy), (*ch).Datetime,
+	)
+	if err != nil {
+		return
+	}
+
+	// get the last inserted id
+	id, err := res.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	// set the id
+	(*ch).Id = int(id)
+
+	return
+}
+
+// FindTopActiveCustomersByAmountSpent returns the customers of the condition of the filter with invoices
+// of its period ordered by the sum of their invoices total, up to its limit.
+// The condition is the one at f.ConditionAt, if it is set.
+func (r *CustomersMySQL) FindTopActiveCustomersByAmountSpent(ctx context.Context, f internal.ReportFilter) ([]internal.CustomerSpent, error) {
 	var customersSpent []internal.CustomerSpent
-	rows, err := r.db.Query(
+	where, args := reportWhere(f)
+	rows, err := r.db.QueryContext(ctx,
 		"SELECT c.`first_name`, c.`last_name`, SUM(i.`total`) AS `total` "+
-			"FROM customers as c INNER JOIN invoices as i ON c.`id` = i.`customer_id` "+
-			"WHERE c.`condition` = 1 "+
+			"FROM customers as c INNER JOIN invoices as i ON c.`id` = i.`customer_id`"+where+" "+
 			"GROUP BY c.`id` ORDER BY `total` DESC LIMIT ?",
-		limit,
+		append(args, f.Limit)...,
 	)
 	if err != nil {
 		return nil, err
@@ -98,12 +335,17 @@ func (r *CustomersMySQL) FindTopActiveCustomersByAmountSpent(limit int) ([]inter
 	return customersSpent, nil
 }
 
-func (r *CustomersMySQL) FindInvoicesByCondition() ([]internal.CustomerInvoicesByCondition, error) {
+// FindInvoicesByCondition returns the total of the invoices of the period of the filter grouped by customer condition.
+// The condition is the one at f.ConditionAt, if it is set.
+func (r *CustomersMySQL) FindInvoicesByCondition(ctx context.Context, f internal.ReportFilter) ([]internal.CustomerInvoicesByCondition, error) {
 	var customersCondition []internal.CustomerInvoicesByCondition
-	rows, err := r.db.Query(
-		"SELECT c.`condition`, ROUND(SUM(i.`total`), 2) AS `total` " +
-			"FROM customers as c INNER JOIN invoices as i ON c.`id` = i.`customer_id` " +
-			"GROUP BY c.`condition`",
+	condition, args := conditionAt(f)
+	where, whereArgs := reportWhere(f)
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+condition+" AS `condition_at`, SUM(i.`total`) AS `total` "+
+			"FROM customers as c INNER JOIN invoices as i ON c.`id` = i.`customer_id`"+where+" "+
+			"GROUP BY `condition_at`",
+		append(args, whereArgs...)...,
 	)
 	if err != nil {
 		return nil, err
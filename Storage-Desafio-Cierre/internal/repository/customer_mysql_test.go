@@ -0,0 +1,61 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"app/internal"
+	"app/internal/repository"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomersMySQL_Delete(t *testing.T) {
+	t.Run("should delete a customer without invoices", func(t *testing.T) {
+		rp := repository.NewCustomersMySQL(OpenTestDB(t))
+
+		err := rp.Delete(context.Background(), 3, false)
+
+		assert.NoError(t, err)
+		_, err = rp.FindById(context.Background(), 3)
+		assert.ErrorIs(t, err, internal.ErrCustomerNotFound)
+	})
+
+	t.Run("should refuse to delete a customer with invoices without cascade", func(t *testing.T) {
+		rp := repository.NewCustomersMySQL(OpenTestDB(t))
+
+		err := rp.Delete(context.Background(), 1, false)
+
+		assert.ErrorIs(t, err, internal.ErrCustomerHasInvoices)
+		_, err = rp.FindById(context.Background(), 1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should delete the invoices and give their sales back to the stock with cascade", func(t *testing.T) {
+		db := OpenTestDB(t)
+		rp := repository.NewCustomersMySQL(db)
+
+		err := rp.Delete(context.Background(), 2, true)
+
+		assert.NoError(t, err)
+		_, err = rp.FindById(context.Background(), 2)
+		assert.ErrorIs(t, err, internal.ErrCustomerNotFound)
+		_, err = repository.NewInvoicesMySQL(db).FindById(context.Background(), 3)
+		assert.ErrorIs(t, err, internal.ErrInvoiceNotFound)
+		p, err := repository.NewProductsMySQL(db).FindById(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, 13, p.Stock)
+	})
+
+	t.Run("should return not found", func(t *testing.T) {
+		rp := repository.NewCustomersMySQL(OpenTestDB(t))
+
+		for _, cascade := range []bool{false, true} {
+			err := rp.Delete(context.Background(), 99, cascade)
+
+			assert.ErrorIs(t, err, internal.ErrCustomerNotFound, cascade)
+		}
+	})
+}
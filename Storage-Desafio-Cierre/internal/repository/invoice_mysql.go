@@ -1,26 +1,36 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 
 	"app/internal"
 )
 
 // NewInvoicesMySQL creates new mysql repository for invoice entity.
-func NewInvoicesMySQL(db *sql.DB) *InvoicesMySQL {
+// The db can be a *sql.DB or a *sql.Tx.
+func NewInvoicesMySQL(db Querier) *InvoicesMySQL {
 	return &InvoicesMySQL{db}
 }
 
 // InvoicesMySQL is the MySQL repository implementation for invoice entity.
 type InvoicesMySQL struct {
-	// db is the database connection.
-	db *sql.DB
+	// db is the database connection or transaction.
+	db Querier
 }
 
-// FindAll returns all invoices from the database.
-func (r *InvoicesMySQL) FindAll() (i []internal.Invoice, err error) {
+// FindAll returns the invoices of a page from the database.
+func (r *InvoicesMySQL) FindAll(ctx context.Context, page internal.Page) (i []internal.Invoice, err error) {
+	// build the query
+	clause, args, err := pageClause(page, internal.InvoiceSortFields)
+	if err != nil {
+		return
+	}
+
 	// execute the query
-	rows, err := r.db.Query("SELECT `id`, `datetime`, `total`, `customer_id` FROM invoices")
+	rows, err := r.db.QueryContext(ctx, "SELECT `id`, `datetime`, `total`, `customer_id` FROM invoices"+clause, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -30,7 +40,7 @@ func (r *InvoicesMySQL) FindAll() (i []internal.Invoice, err error) {
 	for rows.Next() {
 		var iv internal.Invoice
 		// scan the row into the invoice
-		err := rows.Scan(&iv.Id, &iv.Datetime, &iv.Total, &iv.CustomerId)
+		err := rows.Scan(&iv.Id, nullTime{&iv.Datetime}, &iv.Total, &iv.CustomerId)
 		if err != nil {
 			return nil, err
 		}
@@ -45,38 +55,249 @@ func (r *InvoicesMySQL) FindAll() (i []internal.Invoice, err error) {
 	return
 }
 
-// Save saves the invoice into the database.
-func (r *InvoicesMySQL) Save(i *internal.Invoice) (err error) {
+// FindById returns the invoice with the given id from the database.
+func (r *InvoicesMySQL) FindById(ctx context.Context, id int) (i internal.Invoice, err error) {
 	// execute the query
-	res, err := r.db.Exec(
-		"INSERT INTO invoices (`datetime`, `total`, `customer_id`) VALUES (?, ?, ?)",
-		(*i).Datetime, (*i).Total, (*i).CustomerId,
+	row := r.db.QueryRowContext(ctx, "SELECT `id`, `datetime`, `total`, `customer_id` FROM invoices WHERE `id` = ?", id)
+
+	// scan the row into the invoice
+	err = row.Scan(&i.Id, nullTime{&i.Datetime}, &i.Total, &i.CustomerId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = internal.ErrInvoiceNotFound
+		}
+		return
+	}
+
+	return
+}
+
+// FindDocument returns the invoice with the given id from the database with its customer
+// and the lines of its sales, in the order they were saved.
+func (r *InvoicesMySQL) FindDocument(ctx context.Context, id int) (d internal.InvoiceDocument, err error) {
+	// - invoice with its customer
+	row := r.db.QueryRowContext(ctx,
+		"SELECT i.`id`, i.`datetime`, i.`total`, i.`customer_id`, c.`id`, c.`first_name`, c.`last_name`, c.`condition` "+
+			"FROM invoices i INNER JOIN customers c ON c.`id` = i.`customer_id` WHERE i.`id` = ?",
+		id,
 	)
+	err = row.Scan(&d.Id, nullTime{&d.Datetime}, &d.Total, &d.CustomerId,
+		&d.Customer.Id, &d.Customer.FirstName, &d.Customer.LastName, &d.Customer.Condition)
 	if err != nil {
-		return err
+		if errors.Is(err, sql.ErrNoRows) {
+			err = internal.ErrInvoiceNotFound
+		}
+		return
 	}
 
-	// get the last inserted id
-	id, err := res.LastInsertId()
+	// - lines
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT s.`id`, s.`product_id`, p.`description`, s.`unit_price`, s.`quantity` "+
+			"FROM sales s INNER JOIN products p ON p.`id` = s.`product_id` WHERE s.`invoice_id` = ? ORDER BY s.`id`",
+		id,
+	)
 	if err != nil {
-		return err
+		return
 	}
+	defer rows.Close()
 
-	// set the id
-	(*i).Id = int(id)
+	for rows.Next() {
+		var l internal.InvoiceLine
+		// scan the row into the line
+		err = rows.Scan(&l.SaleId, &l.ProductId, &l.Description, &l.UnitPrice, &l.Quantity)
+		if err != nil {
+			return
+		}
+		l.Total = l.UnitPrice.Mul(l.Quantity)
+		d.Lines = append(d.Lines, l)
+	}
+	err = rows.Err()
+	return
+}
+
+// Count returns the number of invoices in the database.
+func (r *InvoicesMySQL) Count(ctx context.Context) (n int, err error) {
+	n, err = count(ctx, r.db, "invoices")
+	return
+}
+
+// Save saves the invoice into the database with a zero total, it is set as the sales of the invoice are saved.
+// A non-zero id is preserved, otherwise the database assigns one.
+func (r *InvoicesMySQL) Save(ctx context.Context, i *internal.Invoice) (err error) {
+	err = insertInvoice(ctx, r.db, i)
+	return
+}
+
+// SaveWithSales saves the invoice and its sales into the database in a single transaction,
+// at the current prices of their products, then sets the total of the invoice from the sales.
+func (r *InvoicesMySQL) SaveWithSales(ctx context.Context, i *internal.Invoice, s []internal.Sale) (err error) {
+	err = transaction(ctx, r.db, func(tx Querier) (err error) {
+		// invoice
+		err = insertInvoice(ctx, tx, i)
+		if err != nil {
+			return
+		}
 
+		// sales
+		for ix := range s {
+			s[ix].InvoiceId = (*i).Id
+			err = insertSale(ctx, tx, &s[ix])
+			if err != nil {
+				return
+			}
+		}
+
+		// total
+		err = updateInvoiceTotal(ctx, tx, (*i).Id)
+		if err != nil {
+			return
+		}
+		err = tx.QueryRowContext(ctx, "SELECT `total` FROM invoices WHERE `id` = ?", (*i).Id).Scan(&(*i).Total)
+		return
+	})
 	return
 }
 
-func (r *InvoicesMySQL) UpdateTotal() error {
+// Update updates the datetime and customer of the invoice in the database and reads its total back.
+func (r *InvoicesMySQL) Update(ctx context.Context, i *internal.Invoice) (err error) {
+	// check the customer exists
+	err = checkInvoiceReferences(ctx, r.db, i)
+	if err != nil {
+		return
+	}
+
+	// execute the query, the total is owned by updateInvoiceTotal
+	_, err = exec(ctx, r.db,
+		"UPDATE invoices SET `datetime` = ?, `customer_id` = ? WHERE `id` = ?",
+		nullableTime((*i).Datetime), (*i).CustomerId, (*i).Id,
+	)
+	if err != nil {
+		return
+	}
+
+	// read the total, checking the invoice exists
+	err = r.db.QueryRowContext(ctx, "SELECT `total` FROM invoices WHERE `id` = ?", (*i).Id).Scan(&(*i).Total)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = internal.ErrInvoiceNotFound
+	}
+	return
+}
+
+// Delete deletes the invoice from the database.
+// The foreign keys cascade the delete to its sales, whose quantities are given back
+// to the stock in the same transaction.
+func (r *InvoicesMySQL) Delete(ctx context.Context, id int) (err error) {
+	err = transaction(ctx, r.db, func(tx Querier) (err error) {
+		// stock
+		err = revertSalesStock(ctx, tx, "`invoice_id` = ?", id)
+		if err != nil {
+			return
+		}
+
+		// execute the query
+		res, err := exec(ctx, tx, "DELETE FROM invoices WHERE `id` = ?", id)
+		if err != nil {
+			return
+		}
+
+		// check the invoice existed
+		n, err := res.RowsAffected()
+		if err != nil {
+			return
+		}
+		if n == 0 {
+			err = internal.ErrInvoiceNotFound
+		}
+		return
+	})
+	return
+}
+
+// checkInvoiceReferences returns ErrInvoiceInvalidReference if the customer of the invoice does not exist.
+func checkInvoiceReferences(ctx context.Context, q Querier, i *internal.Invoice) (err error) {
+	ok, err := exists(ctx, q, "customers", (*i).CustomerId)
+	if err != nil {
+		return
+	}
+	if !ok {
+		return fmt.Errorf("%w: customer %d", internal.ErrInvoiceInvalidReference, (*i).CustomerId)
+	}
+
+	return
+}
+
+// updateInvoiceTotal sets the total of an invoice to the sum of quantity * unit price of its sales,
+// or zero if it has no sales.
+func updateInvoiceTotal(ctx context.Context, q Querier, id int) (err error) {
+	_, err = exec(ctx, q,
+		"UPDATE `invoices` as i SET i.`total` = "+
+			"(SELECT COALESCE(SUM(s.`quantity` * s.`unit_price`), 0) FROM `sales` s WHERE s.`invoice_id` = i.`id`) "+
+			"WHERE i.`id` = ?",
+		id,
+	)
+	return
+}
+
+// UpdateTotal sets the total of every invoice to the sum of quantity * unit price of its sales,
+// or zero if it has no sales.
+func (r *InvoicesMySQL) UpdateTotal(ctx context.Context) error {
 	var err error
-	_, err = r.db.Exec(
-		"UPDATE `invoices` as i SET i.`total` = " +
-			"(SELECT SUM(s.`quantity` * p.`price`) FROM `sales` s INNER JOIN `products` p ON s.`product_id` = p.`id` " +
-			"WHERE s.`invoice_id` = i.`id`)",
+	_, err = exec(ctx, r.db,
+		"UPDATE `invoices` as i SET i.`total` = "+
+			"(SELECT COALESCE(SUM(s.`quantity` * s.`unit_price`), 0) FROM `sales` s WHERE s.`invoice_id` = i.`id`)",
 	)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// UpdateTotalById sets the total of the invoice to the sum of quantity * unit price of its sales,
+// or zero if it has no sales, and returns the invoice updated.
+func (r *InvoicesMySQL) UpdateTotalById(ctx context.Context, id int) (i internal.Invoice, err error) {
+	err = transaction(ctx, r.db, func(tx Querier) (err error) {
+		err = updateInvoiceTotal(ctx, tx, id)
+		if err != nil {
+			return
+		}
+
+		row := tx.QueryRowContext(ctx, "SELECT `id`, `datetime`, `total`, `customer_id` FROM invoices WHERE `id` = ?", id)
+		err = row.Scan(&i.Id, nullTime{&i.Datetime}, &i.Total, &i.CustomerId)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = internal.ErrInvoiceNotFound
+		}
+		return
+	})
+	return
+}
+
+// insertInvoice checks the references of the invoice and inserts it with a zero total, setting its id and total.
+// The total is owned by updateInvoiceTotal.
+func insertInvoice(ctx context.Context, q Querier, i *internal.Invoice) (err error) {
+	// check the customer exists
+	err = checkInvoiceReferences(ctx, q, i)
+	if err != nil {
+		return
+	}
+
+	// execute the query
+	(*i).Total = 0
+	res, err := exec(ctx, q,
+		"INSERT INTO invoices (`id`, `datetime`, `total`, `customer_id`) VALUES (?, ?, ?, ?)",
+		nullableId((*i).Id), nullableTime((*i).Datetime), (*i).Total, (*i).CustomerId,
+	)
+	if err != nil {
+		return err
+	}
+
+	// get the last inserted id
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// set the id
+	(*i).Id = int(id)
+
+	return
+}
@@ -0,0 +1,233 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"app/internal"
+)
+
+// NewCustomersMemory creates new in-memory repository for customer entity.
+func NewCustomersMemory(st *Store) *CustomersMemory {
+	return &CustomersMemory{st}
+}
+
+// CustomersMemory is the in-memory repository implementation for customer entity.
+type CustomersMemory struct {
+	// st is the shared store.
+	st *Store
+}
+
+// FindAll returns the customers of a page.
+func (r *CustomersMemory) FindAll(ctx context.Context, page internal.Page) (c []internal.Customer, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	all := make([]internal.Customer, 0, len(r.st.customers))
+	for _, v := range r.st.customers {
+		all = append(all, v)
+	}
+	c, err = paginate(all, page, internal.CustomerSortFields)
+	return
+}
+
+// Count returns the number of customers.
+func (r *CustomersMemory) Count(ctx context.Context) (n int, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	n = len(r.st.customers)
+	return
+}
+
+// Save saves the customer.
+// A non-zero id is preserved, otherwise the store assigns one.
+func (r *CustomersMemory) Save(ctx context.Context, c *internal.Customer) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	err = r.st.saveCustomer(c)
+	return
+}
+
+// SaveAll saves the customers at once, only if commit is true and every customer can be saved.
+func (r *CustomersMemory) SaveAll(ctx context.Context, c []internal.Customer, commit bool) (errs []error, err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	lastCustomerId := r.st.lastCustomerId
+	errs = make([]error, len(c))
+	var saved []int
+	for ix := range c {
+		errs[ix] = r.st.saveCustomer(&c[ix])
+		if errs[ix] == nil {
+			saved = append(saved, c[ix].Id)
+		}
+	}
+	if len(saved) == len(c) && commit {
+		return
+	}
+
+	// roll back
+	for _, id := range saved {
+		delete(r.st.customers, id)
+	}
+	r.st.lastCustomerId = lastCustomerId
+	return
+}
+
+// FindById returns the customer with the given id.
+func (r *CustomersMemory) FindById(ctx context.Context, id int) (c internal.Customer, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	c, ok := r.st.customers[id]
+	if !ok {
+		err = internal.ErrCustomerNotFound
+	}
+	return
+}
+
+// Update updates the customer, recording a change of its condition as made by the actor of ctx.
+func (r *CustomersMemory) Update(ctx context.Context, c *internal.Customer) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	current, ok := r.st.customers[(*c).Id]
+	if !ok {
+		return internal.ErrCustomerNotFound
+	}
+	if current.Condition != (*c).Condition {
+		r.st.changeCondition((*c).Id, (*c).Condition, internal.ActorFromContext(ctx))
+	}
+	r.st.customers[(*c).Id] = *c
+	return
+}
+
+// Delete deletes the customer together with its invoices and their sales and its condition history,
+// as the foreign keys do, or returns ErrCustomerHasInvoices if it has invoices and cascade is false.
+func (r *CustomersMemory) Delete(ctx context.Context, id int, cascade bool) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	if _, ok := r.st.customers[id]; !ok {
+		return internal.ErrCustomerNotFound
+	}
+	if !cascade {
+		for _, iv := range r.st.invoices {
+			if iv.CustomerId == id {
+				return internal.ErrCustomerHasInvoices
+			}
+		}
+	}
+	delete(r.st.customers, id)
+	for ivId, iv := range r.st.invoices {
+		if iv.CustomerId == id {
+			r.st.deleteInvoice(ivId)
+		}
+	}
+	for chId, ch := range r.st.changes {
+		if ch.CustomerId == id {
+			delete(r.st.changes, chId)
+		}
+	}
+	return
+}
+
+// ChangeCondition sets the condition of the customer and records the change as made by the actor of ctx.
+func (r *CustomersMemory) ChangeCondition(ctx context.Context, ch *internal.CustomerConditionChange) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	cs, ok := r.st.customers[(*ch).CustomerId]
+	if !ok {
+		return internal.ErrCustomerNotFound
+	}
+	if cs.Condition == (*ch).Condition {
+		return fmt.Errorf("%w: customer %d is already %s", internal.ErrCustomerConditionUnchanged, cs.Id, cs.Condition)
+	}
+	*ch = r.st.changeCondition(cs.Id, (*ch).Condition, internal.ActorFromContext(ctx))
+	return
+}
+
+// FindConditionHistory returns the condition changes of the customer ordered by datetime.
+func (r *CustomersMemory) FindConditionHistory(ctx context.Context, id int) (h []internal.CustomerConditionChange, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	h = r.st.conditionHistory(id)
+	return
+}
+
+// FindTopActiveCustomersByAmountSpent returns the customers of the condition of the filter with invoices
+// of its period ordered by the sum of their invoices total, up to its limit.
+// The condition is the one at f.ConditionAt, if it is set.
+func (r *CustomersMemory) FindTopActiveCustomersByAmountSpent(ctx context.Context, f internal.ReportFilter) (c []internal.CustomerSpent, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	// group the invoices total by customer
+	totals := make(map[int]internal.Money)
+	for _, iv := range r.st.invoices {
+		if _, ok := r.st.customers[iv.CustomerId]; !ok || !r.st.inReport(iv, f) {
+			continue
+		}
+		totals[iv.CustomerId] += iv.Total
+	}
+
+	ids := make([]int, 0, len(totals))
+	for id := range totals {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if totals[ids[i]] != totals[ids[j]] {
+			return totals[ids[i]] > totals[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if f.Limit > 0 && len(ids) > f.Limit {
+		ids = ids[:f.Limit]
+	}
+
+	for _, id := range ids {
+		cs := r.st.customers[id]
+		c = append(c, internal.CustomerSpent{
+			FirstName: cs.FirstName,
+			LastName:  cs.LastName,
+			Total:     totals[id],
+		})
+	}
+	return
+}
+
+// FindInvoicesByCondition returns the total of the invoices of the period of the filter grouped by customer condition
+// in the order each condition first appears.
+// The condition is the one at f.ConditionAt, if it is set.
+func (r *CustomersMemory) FindInvoicesByCondition(ctx context.Context, f internal.ReportFilter) (c []internal.CustomerInvoicesByCondition, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	var conditions []internal.CustomerCondition
+	totals := make(map[internal.CustomerCondition]internal.Money)
+	for _, id := range sortedIds(r.st.invoices) {
+		iv := r.st.invoices[id]
+		cs, ok := r.st.customers[iv.CustomerId]
+		if !ok || !r.st.inReport(iv, f) {
+			continue
+		}
+		condition := r.st.conditionAt(cs, f.ConditionAt)
+		if _, ok := totals[condition]; !ok {
+			conditions = append(conditions, condition)
+		}
+		totals[condition] += iv.Total
+	}
+
+	for _, condition := range conditions {
+		c = append(c, internal.CustomerInvoicesByCondition{
+			Condition: condition,
+			Total:     totals[condition],
+		})
+	}
+	return
+}
@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"app/internal"
+)
+
+// NewInvoicesMemory creates new in-memory repository for invoice entity.
+func NewInvoicesMemory(st *Store) *InvoicesMemory {
+	return &InvoicesMemory{st}
+}
+
+// InvoicesMemory is the in-memory repository implementation for invoice entity.
+type InvoicesMemory struct {
+	// st is the shared store.
+	st *Store
+}
+
+// FindAll returns the invoices of a page.
+func (r *InvoicesMemory) FindAll(ctx context.Context, page internal.Page) (i []internal.Invoice, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	all := make([]internal.Invoice, 0, len(r.st.invoices))
+	for _, v := range r.st.invoices {
+		all = append(all, v)
+	}
+	i, err = paginate(all, page, internal.InvoiceSortFields)
+	return
+}
+
+// Count returns the number of invoices.
+func (r *InvoicesMemory) Count(ctx context.Context) (n int, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	n = len(r.st.invoices)
+	return
+}
+
+// FindById returns the invoice with the given id.
+func (r *InvoicesMemory) FindById(ctx context.Context, id int) (i internal.Invoice, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	i, ok := r.st.invoices[id]
+	if !ok {
+		err = internal.ErrInvoiceNotFound
+	}
+	return
+}
+
+// FindDocument returns the invoice with the given id with its customer and the lines of its sales,
+// in the order they were saved.
+func (r *InvoicesMemory) FindDocument(ctx context.Context, id int) (d internal.InvoiceDocument, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	iv, ok := r.st.invoices[id]
+	if !ok {
+		err = internal.ErrInvoiceNotFound
+		return
+	}
+	d.Invoice = iv
+	d.Customer = r.st.customers[iv.CustomerId]
+
+	for _, sid := range sortedIds(r.st.sales) {
+		s := r.st.sales[sid]
+		if s.InvoiceId != id {
+			continue
+		}
+		p := r.st.products[s.ProductId]
+		d.Lines = append(d.Lines, internal.InvoiceLine{
+			SaleId:      s.Id,
+			ProductId:   s.ProductId,
+			Description: p.Description,
+			Quantity:    s.Quantity,
+			UnitPrice:   s.UnitPrice,
+			Total:       s.UnitPrice.Mul(s.Quantity),
+		})
+	}
+	return
+}
+
+// Save saves the invoice.
+// A non-zero id is preserved, otherwise the store assigns one.
+func (r *InvoicesMemory) Save(ctx context.Context, i *internal.Invoice) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	if _, ok := r.st.invoices[(*i).Id]; ok {
+		return ErrDuplicateId
+	}
+	if _, ok := r.st.customers[(*i).CustomerId]; !ok {
+		return fmt.Errorf("%w: customer %d", internal.ErrInvoiceInvalidReference, (*i).CustomerId)
+	}
+	(*i).Id = nextId((*i).Id, &r.st.lastInvoiceId)
+	r.st.invoices[(*i).Id] = *i
+	return
+}
+
+// SaveWithSales saves the invoice and its sales at once, taking their quantities from the stock,
+// at the current prices of their products, then sets the total of the invoice from the sales.
+// Nothing is saved if the invoice or any sale is not valid, or the stock is not enough for all of them.
+func (r *InvoicesMemory) SaveWithSales(ctx context.Context, i *internal.Invoice, s []internal.Sale) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	// validate
+	if _, ok := r.st.invoices[(*i).Id]; ok {
+		return ErrDuplicateId
+	}
+	if _, ok := r.st.customers[(*i).CustomerId]; !ok {
+		return fmt.Errorf("%w: customer %d", internal.ErrInvoiceInvalidReference, (*i).CustomerId)
+	}
+	take := make(map[int]int)
+	for _, sa := range s {
+		if _, ok := r.st.sales[sa.Id]; ok {
+			return ErrDuplicateId
+		}
+		if _, ok := r.st.products[sa.ProductId]; !ok {
+			return fmt.Errorf("%w: product %d", internal.ErrSaleInvalidReference, sa.ProductId)
+		}
+		take[sa.ProductId] += sa.Quantity
+	}
+	err = r.st.checkStock(take, nil)
+	if err != nil {
+		return
+	}
+
+	// save
+	(*i).Id = nextId((*i).Id, &r.st.lastInvoiceId)
+	r.st.invoices[(*i).Id] = *i
+	for ix := range s {
+		s[ix].InvoiceId = (*i).Id
+		s[ix].UnitPrice = r.st.products[s[ix].ProductId].Price
+		s[ix].Id = nextId(s[ix].Id, &r.st.lastSaleId)
+		r.st.sales[s[ix].Id] = s[ix]
+		r.st.moveStock(s[ix].ProductId, -s[ix].Quantity, internal.StockReasonSale, s[ix].Id)
+	}
+	r.st.updateInvoiceTotal((*i).Id)
+	(*i).Total = r.st.invoices[(*i).Id].Total
+	return
+}
+
+// Update updates the datetime and customer of the invoice, keeping its total.
+func (r *InvoicesMemory) Update(ctx context.Context, i *internal.Invoice) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	current, ok := r.st.invoices[(*i).Id]
+	if !ok {
+		return internal.ErrInvoiceNotFound
+	}
+	if _, ok := r.st.customers[(*i).CustomerId]; !ok {
+		return fmt.Errorf("%w: customer %d", internal.ErrInvoiceInvalidReference, (*i).CustomerId)
+	}
+	(*i).Total = current.Total
+	r.st.invoices[(*i).Id] = *i
+	return
+}
+
+// Delete deletes the invoice together with its sales, as the foreign keys do,
+// giving their quantities back to the stock.
+func (r *InvoicesMemory) Delete(ctx context.Context, id int) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	if _, ok := r.st.invoices[id]; !ok {
+		return internal.ErrInvoiceNotFound
+	}
+	r.st.deleteInvoice(id)
+	return
+}
+
+// UpdateTotal sets the total of every invoice to the sum of quantity * unit price of its sales,
+// or zero if it has no sales.
+func (r *InvoicesMemory) UpdateTotal(ctx context.Context) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	for id := range r.st.invoices {
+		r.st.updateInvoiceTotal(id)
+	}
+	return
+}
+
+// UpdateTotalById sets the total of the invoice to the sum of quantity * unit price of its sales,
+// or zero if it has no sales, and returns the invoice updated.
+func (r *InvoicesMemory) UpdateTotalById(ctx context.Context, id int) (i internal.Invoice, err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	if _, ok := r.st.invoices[id]; !ok {
+		err = internal.ErrInvoiceNotFound
+		return
+	}
+	r.st.updateInvoiceTotal(id)
+	i = r.st.invoices[id]
+	return
+}
@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"app/internal"
+)
+
+// sortable is an entity that can be sorted by its sort fields.
+type sortable interface {
+	SortValue(field string) (value any)
+}
+
+// paginate sorts the items and returns the ones of the page, as the MySQL repositories do.
+func paginate[T sortable](items []T, p internal.Page, sortFields []string) (page []T, err error) {
+	field := p.SortField()
+	if !slices.Contains(sortFields, field) {
+		err = fmt.Errorf("%w: can not sort by %s", internal.ErrPageInvalid, field)
+		return
+	}
+
+	// - order: ties are broken by id
+	less := func(a, b T) bool {
+		c := compare(a.SortValue(field), b.SortValue(field))
+		if c == 0 {
+			c = compare(a.SortValue("id"), b.SortValue("id"))
+		}
+		if p.Desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	sort.SliceStable(items, func(i, j int) bool { return less(items[i], items[j]) })
+
+	// - cursor or offset
+	start := 0
+	if p.After != nil {
+		start = len(items)
+		for ix, v := range items {
+			c := compare(v.SortValue(field), p.After.Value)
+			if c == 0 {
+				c = compare(v.SortValue("id"), p.After.Id)
+			}
+			if (!p.Desc && c > 0) || (p.Desc && c < 0) {
+				start = ix
+				break
+			}
+		}
+	} else if p.Offset > 0 {
+		start = min(p.Offset, len(items))
+	}
+	page = items[start:]
+
+	// - limit
+	if p.Limit > 0 && len(page) > p.Limit {
+		page = page[:p.Limit]
+	}
+	return
+}
+
+// compare compares two sort values, numbers of any type are compared as float64
+// and times as instants.
+func compare(a, b any) int {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Compare(tb)
+		}
+	}
+
+	fa, okA := number(a)
+	fb, okB := number(b)
+	if okA && okB {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+
+	sa, sb := fmt.Sprint(a), fmt.Sprint(b)
+	switch {
+	case sa < sb:
+		return -1
+	case sa > sb:
+		return 1
+	}
+	return 0
+}
+
+// number returns a numeric value as float64, money is returned in units as cursors carry it
+// and conditions by their number, as they are stored.
+func number(v any) (f float64, ok bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case internal.CustomerCondition:
+		return float64(n), true
+	case internal.Money:
+		return n.Float64(), true
+	case float64:
+		return n, true
+	}
+	return
+}
@@ -0,0 +1,301 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"app/internal"
+)
+
+// NewProductsMemory creates new in-memory repository for product entity.
+func NewProductsMemory(st *Store) *ProductsMemory {
+	return &ProductsMemory{st}
+}
+
+// ProductsMemory is the in-memory repository implementation for product entity.
+type ProductsMemory struct {
+	// st is the shared store.
+	st *Store
+}
+
+// FindAll returns the products of a page.
+func (r *ProductsMemory) FindAll(ctx context.Context, page internal.Page) (p []internal.Product, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	all := make([]internal.Product, 0, len(r.st.products))
+	for _, v := range r.st.products {
+		all = append(all, v)
+	}
+	p, err = paginate(all, page, internal.ProductSortFields)
+	return
+}
+
+// Count returns the number of products.
+func (r *ProductsMemory) Count(ctx context.Context) (n int, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	n = len(r.st.products)
+	return
+}
+
+// Save saves the product, recording its stock as the initial stock movement.
+// A non-zero id is preserved, otherwise the store assigns one.
+func (r *ProductsMemory) Save(ctx context.Context, p *internal.Product) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	err = r.st.saveProduct(p)
+	return
+}
+
+// SaveAll saves the products at once, only if commit is true and every product can be saved.
+func (r *ProductsMemory) SaveAll(ctx context.Context, p []internal.Product, commit bool) (errs []error, err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	lastProductId, lastStockMovementId := r.st.lastProductId, r.st.lastStockMovementId
+	errs = make([]error, len(p))
+	var saved []int
+	for ix := range p {
+		errs[ix] = r.st.saveProduct(&p[ix])
+		if errs[ix] == nil {
+			saved = append(saved, p[ix].Id)
+		}
+	}
+	if len(saved) == len(p) && commit {
+		return
+	}
+
+	// roll back
+	for _, id := range saved {
+		delete(r.st.products, id)
+	}
+	for id := range r.st.movements {
+		if id > lastStockMovementId {
+			delete(r.st.movements, id)
+		}
+	}
+	r.st.lastProductId, r.st.lastStockMovementId = lastProductId, lastStockMovementId
+	return
+}
+
+// FindById returns the product with the given id.
+func (r *ProductsMemory) FindById(ctx context.Context, id int) (p internal.Product, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	p, ok := r.st.products[id]
+	if !ok {
+		err = internal.ErrProductNotFound
+	}
+	return
+}
+
+// Update updates the product, except its stock which is set to the current one.
+func (r *ProductsMemory) Update(ctx context.Context, p *internal.Product) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	current, ok := r.st.products[(*p).Id]
+	if !ok {
+		return internal.ErrProductNotFound
+	}
+	(*p).Stock = current.Stock
+	r.st.products[(*p).Id] = *p
+	return
+}
+
+// Delete deletes the product together with its stock movements, as the foreign keys do,
+// or returns ErrProductHasSales if it has sales.
+func (r *ProductsMemory) Delete(ctx context.Context, id int) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	if _, ok := r.st.products[id]; !ok {
+		return internal.ErrProductNotFound
+	}
+	for _, sa := range r.st.sales {
+		if sa.ProductId == id {
+			return internal.ErrProductHasSales
+		}
+	}
+	delete(r.st.products, id)
+	for mvId, mv := range r.st.movements {
+		if mv.ProductId == id {
+			delete(r.st.movements, mvId)
+		}
+	}
+	return
+}
+
+// FindTopProductsByAmount returns the products with sales in the invoices of the filter of the query ranked by
+// the sum of their sold quantity or of quantity * unit price, up to its limit.
+// The share is the revenue of the product over the one of every product sold in those invoices.
+func (r *ProductsMemory) FindTopProductsByAmount(ctx context.Context, q internal.TopProductsQuery) (p []internal.ProductAmount, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	// group the sold quantity and revenue by product
+	units := make(map[int]int)
+	revenues := make(map[int]internal.Money)
+	var total internal.Money
+	for _, sa := range r.st.sales {
+		if _, ok := r.st.products[sa.ProductId]; !ok {
+			continue
+		}
+		if iv, ok := r.st.invoices[sa.InvoiceId]; !ok || !r.st.inReport(iv, q.ReportFilter) {
+			continue
+		}
+		units[sa.ProductId] += sa.Quantity
+		revenues[sa.ProductId] += sa.UnitPrice.Mul(sa.Quantity)
+		total += sa.UnitPrice.Mul(sa.Quantity)
+	}
+
+	ids := make([]int, 0, len(units))
+	for id := range units {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := ids[i], ids[j]
+		if q.RankBy == internal.RankByRevenue && revenues[a] != revenues[b] {
+			return revenues[a] > revenues[b]
+		}
+		if units[a] != units[b] {
+			return units[a] > units[b]
+		}
+		if revenues[a] != revenues[b] {
+			return revenues[a] > revenues[b]
+		}
+		return a < b
+	})
+	if q.Limit > 0 && len(ids) > q.Limit {
+		ids = ids[:q.Limit]
+	}
+
+	for _, id := range ids {
+		pa := internal.ProductAmount{
+			Description: r.st.products[id].Description,
+			Units:       units[id],
+			Revenue:     revenues[id],
+		}
+		if total != 0 {
+			pa.Share = float64(revenues[id]) / float64(total)
+		}
+		p = append(p, pa)
+	}
+	return
+}
+
+// FindBasketStats returns the counts of the invoices of the filter with sales of each product and pair of products.
+func (r *ProductsMemory) FindBasketStats(ctx context.Context, f internal.ReportFilter) (s internal.BasketStats, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	// group the products by invoice
+	baskets := make(map[int]map[int]bool)
+	for _, sa := range r.st.sales {
+		if _, ok := r.st.products[sa.ProductId]; !ok {
+			continue
+		}
+		if iv, ok := r.st.invoices[sa.InvoiceId]; !ok || !r.st.inReport(iv, f) {
+			continue
+		}
+		if baskets[sa.InvoiceId] == nil {
+			baskets[sa.InvoiceId] = make(map[int]bool)
+		}
+		baskets[sa.InvoiceId][sa.ProductId] = true
+	}
+
+	// count the invoices of each product and pair
+	products := make(map[int]int)
+	pairs := make(map[[2]int]int)
+	for _, basket := range baskets {
+		for id := range basket {
+			products[id]++
+			for withId := range basket {
+				if id < withId {
+					pairs[[2]int{id, withId}]++
+				}
+			}
+		}
+	}
+
+	s.Invoices = len(baskets)
+	for _, id := range sortedIds(r.st.products) {
+		if n, ok := products[id]; ok {
+			s.Products = append(s.Products, internal.BasketProduct{ProductId: id, Description: r.st.products[id].Description, Invoices: n})
+		}
+	}
+	for ids, n := range pairs {
+		s.Pairs = append(s.Pairs, internal.BasketPair{ProductId: ids[0], WithProductId: ids[1], Invoices: n})
+	}
+	sort.Slice(s.Pairs, func(i, j int) bool {
+		if s.Pairs[i].ProductId != s.Pairs[j].ProductId {
+			return s.Pairs[i].ProductId < s.Pairs[j].ProductId
+		}
+		return s.Pairs[i].WithProductId < s.Pairs[j].WithProductId
+	})
+	return
+}
+
+// AdjustStock adds the quantity of the movement to the stock of its product and records the movement.
+func (r *ProductsMemory) AdjustStock(ctx context.Context, m *internal.StockMovement) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	if _, ok := r.st.products[(*m).ProductId]; !ok {
+		return internal.ErrProductNotFound
+	}
+	err = r.st.checkStock(map[int]int{(*m).ProductId: -(*m).Quantity}, nil)
+	if err != nil {
+		return
+	}
+	*m = r.st.moveStock((*m).ProductId, (*m).Quantity, (*m).Reason, (*m).SaleId)
+	return
+}
+
+// FindStockMovements returns the stock movements of a product of a page.
+func (r *ProductsMemory) FindStockMovements(ctx context.Context, productId int, page internal.Page) (m []internal.StockMovement, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	var all []internal.StockMovement
+	for _, v := range r.st.movements {
+		if v.ProductId == productId {
+			all = append(all, v)
+		}
+	}
+	m, err = paginate(all, page, internal.StockMovementSortFields)
+	return
+}
+
+// CountStockMovements returns the number of stock movements of a product.
+func (r *ProductsMemory) CountStockMovements(ctx context.Context, productId int) (n int, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	for _, v := range r.st.movements {
+		if v.ProductId == productId {
+			n++
+		}
+	}
+	return
+}
+
+// FindLowStock returns the products with a stock up to threshold, ordered by stock and id.
+func (r *ProductsMemory) FindLowStock(ctx context.Context, threshold int) (p []internal.Product, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	for _, id := range sortedIds(r.st.products) {
+		if pr := r.st.products[id]; pr.Stock <= threshold {
+			p = append(p, pr)
+		}
+	}
+	sort.SliceStable(p, func(i, j int) bool {
+		return p[i].Stock < p[j].Stock
+	})
+	return
+}
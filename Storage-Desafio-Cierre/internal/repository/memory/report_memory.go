@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"app/internal"
+)
+
+// NewReportsMemory creates new in-memory repository for the reports.
+func NewReportsMemory(st *Store) *ReportsMemory {
+	return &ReportsMemory{st}
+}
+
+// ReportsMemory is the in-memory repository implementation for the reports.
+type ReportsMemory struct {
+	// st is the shared store.
+	st *Store
+}
+
+// FindRevenue returns the buckets of the query with invoices, ordered by start and condition.
+// The buckets start at midnight in the location of q.From, the store time zone.
+func (r *ReportsMemory) FindRevenue(ctx context.Context, q internal.RevenueQuery) (b []internal.RevenueBucket, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	// units of each invoice
+	units := make(map[int]int)
+	for _, sa := range r.st.sales {
+		units[sa.InvoiceId] += sa.Quantity
+	}
+
+	// group the invoices by bucket and condition
+	type key struct {
+		start     time.Time
+		condition internal.CustomerCondition
+	}
+	buckets := make(map[key]*internal.RevenueBucket)
+	for _, iv := range r.st.invoices {
+		cs, ok := r.st.customers[iv.CustomerId]
+		if !ok || !r.st.inReport(iv, q.ReportFilter) {
+			continue
+		}
+		k := key{start: q.Interval.Start(iv.Datetime.In(q.From.Location()))}
+		if q.ByCondition {
+			k.condition = r.st.conditionAt(cs, q.ConditionAt)
+		}
+		bu, ok := buckets[k]
+		if !ok {
+			bu = &internal.RevenueBucket{Start: k.start}
+			if q.ByCondition {
+				condition := k.condition
+				bu.Condition = &condition
+			}
+			buckets[k] = bu
+		}
+		bu.Invoices++
+		bu.Units += units[iv.Id]
+		bu.Revenue += iv.Total
+	}
+
+	for _, bu := range buckets {
+		b = append(b, *bu)
+	}
+	sort.Slice(b, func(i, j int) bool {
+		if !b[i].Start.Equal(b[j].Start) {
+			return b[i].Start.Before(b[j].Start)
+		}
+		return b[i].Condition != nil && *b[i].Condition < *b[j].Condition
+	})
+	return
+}
+
+// FindCustomerActivity returns the activity of the customers with invoices of the filter, ordered by id.
+func (r *ReportsMemory) FindCustomerActivity(ctx context.Context, f internal.ReportFilter) (a []internal.CustomerActivity, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	// group the invoices by customer
+	activity := make(map[int]*internal.CustomerActivity)
+	for _, iv := range r.st.invoices {
+		cs, ok := r.st.customers[iv.CustomerId]
+		if !ok || !r.st.inReport(iv, f) {
+			continue
+		}
+		ac, ok := activity[cs.Id]
+		if !ok {
+			ac = &internal.CustomerActivity{CustomerId: cs.Id, FirstName: cs.FirstName, LastName: cs.LastName}
+			activity[cs.Id] = ac
+		}
+		if iv.Datetime.After(ac.LastInvoice) {
+			ac.LastInvoice = iv.Datetime
+		}
+		ac.Invoices++
+		ac.Total += iv.Total
+	}
+
+	for _, id := range sortedIds(r.st.customers) {
+		if ac, ok := activity[id]; ok {
+			a = append(a, *ac)
+		}
+	}
+	return
+}
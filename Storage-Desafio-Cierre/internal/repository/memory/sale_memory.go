@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"app/internal"
+	"context"
+)
+
+// NewSalesMemory creates new in-memory repository for sale entity.
+func NewSalesMemory(st *Store) *SalesMemory {
+	return &SalesMemory{st}
+}
+
+// SalesMemory is the in-memory repository implementation for sale entity.
+type SalesMemory struct {
+	// st is the shared store.
+	st *Store
+}
+
+// FindAll returns the sales of a page.
+func (r *SalesMemory) FindAll(ctx context.Context, page internal.Page) (s []internal.Sale, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	all := make([]internal.Sale, 0, len(r.st.sales))
+	for _, v := range r.st.sales {
+		all = append(all, v)
+	}
+	s, err = paginate(all, page, internal.SaleSortFields)
+	return
+}
+
+// Count returns the number of sales.
+func (r *SalesMemory) Count(ctx context.Context) (n int, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	n = len(r.st.sales)
+	return
+}
+
+// FindById returns the sale with the given id.
+func (r *SalesMemory) FindById(ctx context.Context, id int) (s internal.Sale, err error) {
+	r.st.mu.RLock()
+	defer r.st.mu.RUnlock()
+
+	s, ok := r.st.sales[id]
+	if !ok {
+		err = internal.ErrSaleNotFound
+	}
+	return
+}
+
+// Save saves the sale at the current price of its product, takes its quantity from the stock of the product
+// and recomputes the total of its invoice.
+// A non-zero id is preserved, otherwise the store assigns one.
+func (r *SalesMemory) Save(ctx context.Context, s *internal.Sale) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	if _, ok := r.st.sales[(*s).Id]; ok {
+		return ErrDuplicateId
+	}
+	err = r.st.checkSaleReferences(s)
+	if err != nil {
+		return
+	}
+	err = r.st.checkStock(map[int]int{(*s).ProductId: (*s).Quantity}, nil)
+	if err != nil {
+		return
+	}
+	(*s).UnitPrice = r.st.products[(*s).ProductId].Price
+	(*s).Id = nextId((*s).Id, &r.st.lastSaleId)
+	r.st.sales[(*s).Id] = *s
+	r.st.moveStock((*s).ProductId, -(*s).Quantity, internal.StockReasonSale, (*s).Id)
+
+	r.st.updateInvoiceTotal((*s).InvoiceId)
+	return
+}
+
+// Update updates the sale, moves the stock from the current quantity and product to the new ones
+// and recomputes the total of the invoice it belonged to and the one it belongs to.
+// The sale keeps its unit price unless its product changes, then it takes the current price of the new one.
+func (r *SalesMemory) Update(ctx context.Context, s *internal.Sale) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	current, ok := r.st.sales[(*s).Id]
+	if !ok {
+		return internal.ErrSaleNotFound
+	}
+	err = r.st.checkSaleReferences(s)
+	if err != nil {
+		return
+	}
+	// - the current quantity is given back before taking the new one
+	err = r.st.checkStock(
+		map[int]int{(*s).ProductId: (*s).Quantity},
+		map[int]int{current.ProductId: current.Quantity},
+	)
+	if err != nil {
+		return
+	}
+	(*s).UnitPrice = current.UnitPrice
+	if (*s).ProductId != current.ProductId {
+		(*s).UnitPrice = r.st.products[(*s).ProductId].Price
+	}
+	r.st.sales[(*s).Id] = *s
+	r.st.moveStock(current.ProductId, current.Quantity, internal.StockReasonSaleReverted, current.Id)
+	r.st.moveStock((*s).ProductId, -(*s).Quantity, internal.StockReasonSale, (*s).Id)
+
+	r.st.updateInvoiceTotal((*s).InvoiceId)
+	if current.InvoiceId != (*s).InvoiceId {
+		r.st.updateInvoiceTotal(current.InvoiceId)
+	}
+	return
+}
+
+// Delete deletes the sale, gives its quantity back to the stock of its product
+// and recomputes the total of its invoice.
+func (r *SalesMemory) Delete(ctx context.Context, id int) (err error) {
+	r.st.mu.Lock()
+	defer r.st.mu.Unlock()
+
+	current, ok := r.st.sales[id]
+	if !ok {
+		return internal.ErrSaleNotFound
+	}
+	delete(r.st.sales, id)
+	r.st.moveStock(current.ProductId, current.Quantity, internal.StockReasonSaleReverted, id)
+
+	r.st.updateInvoiceTotal(current.InvoiceId)
+	return
+}
@@ -0,0 +1,250 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"app/internal"
+)
+
+var (
+	// ErrDuplicateId is returned when saving an entity whose id already exists.
+	ErrDuplicateId = fmt.Errorf("%w: memory: duplicate id", internal.ErrConflict)
+)
+
+// NewStore creates a new empty Store.
+func NewStore() *Store {
+	return &Store{
+		customers: make(map[int]internal.Customer),
+		products:  make(map[int]internal.Product),
+		invoices:  make(map[int]internal.Invoice),
+		sales:     make(map[int]internal.Sale),
+		movements: make(map[int]internal.StockMovement),
+		changes:   make(map[int]internal.CustomerConditionChange),
+	}
+}
+
+// Store holds the tables shared by the in-memory repositories,
+// so that joins between entities behave as in the database.
+type Store struct {
+	// mu guards the tables.
+	mu sync.RWMutex
+	// customers is the customers table.
+	customers map[int]internal.Customer
+	// products is the products table.
+	products map[int]internal.Product
+	// invoices is the invoices table.
+	invoices map[int]internal.Invoice
+	// sales is the sales table.
+	sales map[int]internal.Sale
+	// movements is the stock movements table.
+	movements map[int]internal.StockMovement
+	// changes is the customer condition changes table.
+	changes map[int]internal.CustomerConditionChange
+	// lastCustomerId is the auto increment of customers.
+	lastCustomerId int
+	// lastProductId is the auto increment of products.
+	lastProductId int
+	// lastInvoiceId is the auto increment of invoices.
+	lastInvoiceId int
+	// lastSaleId is the auto increment of sales.
+	lastSaleId int
+	// lastStockMovementId is the auto increment of stock movements.
+	lastStockMovementId int
+	// lastConditionChangeId is the auto increment of customer condition changes.
+	lastConditionChangeId int
+}
+
+// nextId returns the id to insert a row with, following MySQL auto increment:
+// a zero id takes the next value and an explicit id moves the counter forward.
+func nextId(id int, last *int) int {
+	if id == 0 {
+		*last++
+		return *last
+	}
+	if id > *last {
+		*last = id
+	}
+	return id
+}
+
+// sortedIds returns the keys of a table in ascending order.
+func sortedIds[T any](table map[int]T) (ids []int) {
+	ids = make([]int, 0, len(table))
+	for id := range table {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return
+}
+
+// deleteInvoice deletes an invoice and its sales, giving their quantities back to the stock.
+// The caller must hold the lock.
+func (st *Store) deleteInvoice(id int) {
+	delete(st.invoices, id)
+	for _, saId := range sortedIds(st.sales) {
+		sa := st.sales[saId]
+		if sa.InvoiceId == id {
+			st.moveStock(sa.ProductId, sa.Quantity, internal.StockReasonSaleReverted, saId)
+			delete(st.sales, saId)
+		}
+	}
+}
+
+// checkStock returns ErrInsufficientStock if the quantity to take of a product is more than its stock
+// plus the quantity given back to it first, e.g. by the sale being updated.
+// The quantities are grouped by product id, so several sales of a product are checked at once.
+// The caller must hold the lock.
+func (st *Store) checkStock(take, giveBack map[int]int) (err error) {
+	for _, id := range sortedIds(take) {
+		pr, ok := st.products[id]
+		if !ok {
+			continue
+		}
+		if available := pr.Stock + giveBack[id]; take[id] > available {
+			return fmt.Errorf("%w: product %d has %d, %d requested", internal.ErrInsufficientStock, id, available, take[id])
+		}
+	}
+	return
+}
+
+// saveCustomer saves the customer, a non-zero id is preserved, otherwise the store assigns one.
+// The caller must hold the lock.
+func (st *Store) saveCustomer(c *internal.Customer) (err error) {
+	if _, ok := st.customers[(*c).Id]; ok {
+		return ErrDuplicateId
+	}
+	(*c).Id = nextId((*c).Id, &st.lastCustomerId)
+	st.customers[(*c).Id] = *c
+	return
+}
+
+// saveProduct saves the product, recording its stock as the initial stock movement.
+// A non-zero id is preserved, otherwise the store assigns one. The caller must hold the lock.
+func (st *Store) saveProduct(p *internal.Product) (err error) {
+	if _, ok := st.products[(*p).Id]; ok {
+		return ErrDuplicateId
+	}
+	(*p).Id = nextId((*p).Id, &st.lastProductId)
+	pr := *p
+	pr.Stock = 0
+	st.products[(*p).Id] = pr
+	if (*p).Stock != 0 {
+		st.moveStock((*p).Id, (*p).Stock, internal.StockReasonInitial, 0)
+	}
+	return
+}
+
+// moveStock adds quantity to the stock of a product and records the movement, which it returns.
+// The caller must hold the lock and have checked the stock with checkStock.
+func (st *Store) moveStock(productId, quantity int, reason string, saleId int) (m internal.StockMovement) {
+	pr := st.products[productId]
+	pr.Stock += quantity
+	st.products[productId] = pr
+
+	m = internal.StockMovement{
+		Id: nextId(0, &st.lastStockMovementId),
+		StockMovementAttributes: internal.StockMovementAttributes{
+			ProductId: productId,
+			Quantity:  quantity,
+			Reason:    reason,
+			SaleId:    saleId,
+			Datetime:  time.Now().Truncate(time.Second),
+			Balance:   pr.Stock,
+		},
+	}
+	st.movements[m.Id] = m
+	return
+}
+
+// updateInvoiceTotal sets the total of an invoice to the sum of quantity * unit price of its sales,
+// or zero if it has no sales. The caller must hold the lock.
+func (st *Store) updateInvoiceTotal(id int) {
+	iv, ok := st.invoices[id]
+	if !ok {
+		return
+	}
+
+	iv.Total = 0
+	for _, sa := range st.sales {
+		if sa.InvoiceId != id {
+			continue
+		}
+		iv.Total += sa.UnitPrice.Mul(sa.Quantity)
+	}
+	st.invoices[id] = iv
+}
+
+// checkSaleReferences returns ErrSaleInvalidReference if the invoice or the product of the sale does not exist.
+// The caller must hold the lock.
+func (st *Store) checkSaleReferences(s *internal.Sale) (err error) {
+	if _, ok := st.invoices[(*s).InvoiceId]; !ok {
+		return fmt.Errorf("%w: invoice %d", internal.ErrSaleInvalidReference, (*s).InvoiceId)
+	}
+	if _, ok := st.products[(*s).ProductId]; !ok {
+		return fmt.Errorf("%w: product %d", internal.ErrSaleInvalidReference, (*s).ProductId)
+	}
+	return
+}
+
+// changeCondition sets the condition of a customer and records the change, which it returns.
+// The caller must hold the lock and have checked the customer exists.
+func (st *Store) changeCondition(customerId int, condition internal.CustomerCondition, actor string) (ch internal.CustomerConditionChange) {
+	cs := st.customers[customerId]
+	ch = internal.CustomerConditionChange{
+		Id:         nextId(0, &st.lastConditionChangeId),
+		CustomerId: customerId,
+		Previous:   cs.Condition,
+		Condition:  condition,
+		ChangedBy:  actor,
+		Datetime:   time.Now().Truncate(time.Second),
+	}
+	st.changes[ch.Id] = ch
+
+	cs.Condition = condition
+	st.customers[customerId] = cs
+	return
+}
+
+// conditionHistory returns the condition changes of a customer ordered by datetime and id.
+// The caller must hold the lock.
+func (st *Store) conditionHistory(customerId int) (h []internal.CustomerConditionChange) {
+	for _, id := range sortedIds(st.changes) {
+		if ch := st.changes[id]; ch.CustomerId == customerId {
+			h = append(h, ch)
+		}
+	}
+	sort.SliceStable(h, func(i, j int) bool {
+		return h[i].Datetime.Before(h[j].Datetime)
+	})
+	return
+}
+
+// conditionAt returns the condition of a customer at t, or its current one if t is zero.
+// The caller must hold the lock.
+func (st *Store) conditionAt(cs internal.Customer, t time.Time) internal.CustomerCondition {
+	if t.IsZero() {
+		return cs.Condition
+	}
+	return internal.ConditionAt(cs.Condition, st.conditionHistory(cs.Id), t)
+}
+
+// inReport reports whether an invoice is in the period of the filter and, if the filter has a condition,
+// whether its customer had it. The caller must hold the lock.
+func (st *Store) inReport(iv internal.Invoice, f internal.ReportFilter) bool {
+	if !f.From.IsZero() && iv.Datetime.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !iv.Datetime.Before(f.To) {
+		return false
+	}
+	if f.Condition != nil {
+		cs, ok := st.customers[iv.CustomerId]
+		if !ok || st.conditionAt(cs, f.ConditionAt) != *f.Condition {
+			return false
+		}
+	}
+	return true
+}
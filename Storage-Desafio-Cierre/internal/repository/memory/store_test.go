@@ -0,0 +1,177 @@
+package memory_test
+
+import (
+	"app/internal"
+	"app/internal/loader"
+	"app/internal/repository/memory"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Insert the same data as the mysql handler tests
+func SetupTestData(t *testing.T, st *memory.Store) {
+	rpCustomer := memory.NewCustomersMemory(st)
+	rpInvoice := memory.NewInvoicesMemory(st)
+
+	customers := []internal.Customer{
+		{Id: 1, CustomerAttributes: internal.CustomerAttributes{FirstName: "Michael", LastName: "Jordan", Condition: 1}},
+		{Id: 2, CustomerAttributes: internal.CustomerAttributes{FirstName: "Sarah", LastName: "Connor", Condition: 1}},
+		{Id: 3, CustomerAttributes: internal.CustomerAttributes{FirstName: "Albert", LastName: "Einstein", Condition: 1}},
+		{Id: 4, CustomerAttributes: internal.CustomerAttributes{FirstName: "Isaac", LastName: "Newton", Condition: 1}},
+		{Id: 5, CustomerAttributes: internal.CustomerAttributes{FirstName: "Marie", LastName: "Curie", Condition: 1}},
+		{Id: 6, CustomerAttributes: internal.CustomerAttributes{FirstName: "Nikola", LastName: "Tesla", Condition: 1}},
+		{Id: 7, CustomerAttributes: internal.CustomerAttributes{FirstName: "Ada", LastName: "Lovelace", Condition: 0}},
+		{Id: 8, CustomerAttributes: internal.CustomerAttributes{FirstName: "Alan", LastName: "Turing", Condition: 0}},
+	}
+	for _, c := range customers {
+		require.NoError(t, rpCustomer.Save(context.Background(), &c))
+	}
+
+	totals := []internal.Money{120000, 65000, 30000, 15000, 7500, 4000, 1500, 800}
+	for ix, total := range totals {
+		i := internal.Invoice{Id: ix + 1, InvoiceAttributes: internal.InvoiceAttributes{CustomerId: ix + 1, Total: total}}
+		require.NoError(t, rpInvoice.Save(context.Background(), &i))
+	}
+}
+
+func TestCustomersMemory_FindTopActiveCustomersByAmountSpent(t *testing.T) {
+	t.Run("should return top active customers based on amount spent", func(t *testing.T) {
+		st := memory.NewStore()
+		SetupTestData(t, st)
+		rp := memory.NewCustomersMemory(st)
+
+		active := internal.CustomerActive
+		c, err := rp.FindTopActiveCustomersByAmountSpent(context.Background(), internal.ReportFilter{Limit: 5, Condition: &active})
+
+		expected := []internal.CustomerSpent{
+			{FirstName: "Michael", LastName: "Jordan", Total: 120000},
+			{FirstName: "Sarah", LastName: "Connor", Total: 65000},
+			{FirstName: "Albert", LastName: "Einstein", Total: 30000},
+			{FirstName: "Isaac", LastName: "Newton", Total: 15000},
+			{FirstName: "Marie", LastName: "Curie", Total: 7500},
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, expected, c)
+	})
+
+	t.Run("should return empty list when no customers match", func(t *testing.T) {
+		rp := memory.NewCustomersMemory(memory.NewStore())
+
+		active := internal.CustomerActive
+		c, err := rp.FindTopActiveCustomersByAmountSpent(context.Background(), internal.ReportFilter{Limit: 5, Condition: &active})
+
+		assert.NoError(t, err)
+		assert.Empty(t, c)
+	})
+}
+
+func TestCustomersMemory_FindInvoicesByCondition(t *testing.T) {
+	t.Run("should return invoices grouped by condition", func(t *testing.T) {
+		st := memory.NewStore()
+		SetupTestData(t, st)
+		rp := memory.NewCustomersMemory(st)
+
+		c, err := rp.FindInvoicesByCondition(context.Background(), internal.ReportFilter{})
+
+		expected := []internal.CustomerInvoicesByCondition{
+			{Condition: 1, Total: 241500},
+			{Condition: 0, Total: 2300},
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, expected, c)
+	})
+
+	t.Run("should only sum the invoices of the period", func(t *testing.T) {
+		st := memory.NewStore()
+		SetupTestData(t, st)
+		i := internal.Invoice{InvoiceAttributes: internal.InvoiceAttributes{
+			CustomerId: 7, Total: 100, Datetime: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		}}
+		require.NoError(t, memory.NewInvoicesMemory(st).Save(context.Background(), &i))
+		rp := memory.NewCustomersMemory(st)
+
+		c, err := rp.FindInvoicesByCondition(context.Background(), internal.ReportFilter{
+			From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		})
+
+		expected := []internal.CustomerInvoicesByCondition{
+			{Condition: 0, Total: 100},
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, expected, c)
+	})
+}
+
+func TestStore_Save(t *testing.T) {
+	t.Run("should assign the next id after the preserved ones", func(t *testing.T) {
+		rp := memory.NewCustomersMemory(memory.NewStore())
+
+		c1 := internal.Customer{Id: 10}
+		c2 := internal.Customer{}
+		assert.NoError(t, rp.Save(context.Background(), &c1))
+		assert.NoError(t, rp.Save(context.Background(), &c2))
+
+		assert.Equal(t, 10, c1.Id)
+		assert.Equal(t, 11, c2.Id)
+	})
+
+	t.Run("should fail on duplicate id", func(t *testing.T) {
+		rp := memory.NewCustomersMemory(memory.NewStore())
+
+		c1 := internal.Customer{Id: 1}
+		c2 := internal.Customer{Id: 1}
+		assert.NoError(t, rp.Save(context.Background(), &c1))
+
+		assert.ErrorIs(t, rp.Save(context.Background(), &c2), memory.ErrDuplicateId)
+	})
+
+	t.Run("should fail when a sale references a missing invoice", func(t *testing.T) {
+		st := memory.NewStore()
+		p := internal.Product{}
+		require.NoError(t, memory.NewProductsMemory(st).Save(context.Background(), &p))
+
+		s := internal.Sale{SaleAttributes: internal.SaleAttributes{Quantity: 1, ProductId: p.Id, InvoiceId: 99}}
+		err := memory.NewSalesMemory(st).Save(context.Background(), &s)
+
+		assert.ErrorIs(t, err, internal.ErrSaleInvalidReference)
+	})
+}
+
+func TestInvoicesMemory_UpdateTotal(t *testing.T) {
+	t.Run("should load the fixtures and compute totals from the sales", func(t *testing.T) {
+		st := memory.NewStore()
+		rpCustomer := memory.NewCustomersMemory(st)
+		rpProduct := memory.NewProductsMemory(st)
+		rpInvoice := memory.NewInvoicesMemory(st)
+		rpSale := memory.NewSalesMemory(st)
+
+		ld := loader.NewLoaderJSON("../../../docs/db/json", time.UTC, rpCustomer, rpProduct, rpInvoice, rpSale)
+		require.NoError(t, ld.Load(context.Background()))
+
+		invoices, err := rpInvoice.FindAll(context.Background(), internal.Page{})
+		require.NoError(t, err)
+		products, err := rpProduct.FindAll(context.Background(), internal.Page{})
+		require.NoError(t, err)
+		sales, err := rpSale.FindAll(context.Background(), internal.Page{})
+		require.NoError(t, err)
+
+		prices := make(map[int]internal.Money)
+		for _, p := range products {
+			prices[p.Id] = p.Price
+		}
+		expected := make(map[int]internal.Money)
+		for _, s := range sales {
+			expected[s.InvoiceId] += prices[s.ProductId].Mul(s.Quantity)
+		}
+		assert.Len(t, invoices, 100)
+		assert.Len(t, sales, 1000)
+		for _, i := range invoices {
+			assert.Equal(t, expected[i.Id], i.Total)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-txdb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	dsn := mysql.Config{
+		User:      "root",
+		Passwd:    "root",
+		Addr:      "127.0.0.1:3306",
+		Net:       "tcp",
+		DBName:    "fantasy_products_test",
+		ParseTime: true,
+	}
+	// Register
+	txdb.Register("txdb", "mysql", dsn.FormatDSN())
+}
+
+// OpenTestDB returns a connection to the test database in a transaction rolled back when the test ends,
+// with the data of SetupTestData
+func OpenTestDB(t *testing.T) (db *sql.DB) {
+	db, err := sql.Open("txdb", t.Name())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	require.NoError(t, SetupTestData(db))
+	return
+}
+
+// SetupTestData inserts an active customer with invoices on 2024-01-01 and 2024-01-03,
+// an inactive one, active until 2024-01-05, with an invoice on 2024-01-10, an active one without invoices,
+// an invoice without customer on 2024-01-03 and a product without sales
+func SetupTestData(db *sql.DB) error {
+	queries := []string{
+		"INSERT INTO customers (`id`, `first_name`, `last_name`, `condition`) VALUES " +
+			"(1, 'Michael', 'Jordan', 1), (2, 'Scottie', 'Pippen', 0), (3, 'Dennis', 'Rodman', 1)",
+		"INSERT INTO customer_condition_changes (`customer_id`, `previous`, `condition`, `changed_by`, `datetime`) VALUES " +
+			"(2, 1, 0, 'admin', '2024-01-05 00:00:00')",
+		"INSERT INTO products (`id`, `description`, `price`, `stock`) VALUES " +
+			"(1, 'Milk', 2.50, 10), (2, 'Bread', 1.00, 5), (3, 'Butter', 4.00, 3)",
+		"INSERT INTO invoices (`id`, `datetime`, `customer_id`, `total`) VALUES " +
+			"(1, '2024-01-01 10:00:00', 1, 6.00), (2, '2024-01-03 10:00:00', 1, 2.50), " +
+			"(3, '2024-01-10 10:00:00', 2, 7.50), (4, '2024-01-03 12:00:00', NULL, 1.00)",
+		"INSERT INTO sales (`id`, `quantity`, `invoice_id`, `product_id`, `unit_price`) VALUES " +
+			"(1, 2, 1, 1, 2.50), (2, 1, 1, 2, 1.00), (3, 1, 2, 1, 2.50), (4, 3, 3, 1, 2.50), (5, 1, 4, 2, 1.00)",
+	}
+
+	for _, query := range queries {
+		_, err := db.Exec(query)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"app/internal"
+)
+
+// pageClause returns the WHERE, ORDER BY and LIMIT clauses that apply a page to a query.
+// The sort fields are the columns the page can be sorted by and ties are broken by id,
+// so a cursor continues right after the last item of the previous page.
+func pageClause(p internal.Page, sortFields []string) (clause string, args []any, err error) {
+	field := p.SortField()
+	if !slices.Contains(sortFields, field) {
+		err = fmt.Errorf("%w: can not sort by %s", internal.ErrPageInvalid, field)
+		return
+	}
+	column := "`" + field + "`"
+	dir, cmp := "ASC", ">"
+	if p.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	// - cursor
+	if p.After != nil {
+		if field == "id" {
+			clause += " WHERE `id` " + cmp + " ?"
+			args = append(args, p.After.Id)
+		} else {
+			clause += fmt.Sprintf(" WHERE (%s %s ? OR (%s = ? AND `id` %s ?))", column, cmp, column, cmp)
+			args = append(args, p.After.Value, p.After.Value, p.After.Id)
+		}
+	}
+
+	// - order
+	clause += " ORDER BY " + column + " " + dir
+	if field != "id" {
+		clause += ", `id` " + dir
+	}
+
+	// - limit: MySQL needs a limit to use an offset
+	offset := p.Offset > 0 && p.After == nil
+	switch {
+	case p.Limit > 0:
+		clause += " LIMIT ?"
+		args = append(args, p.Limit)
+	case offset:
+		clause += " LIMIT 18446744073709551615"
+	}
+	if offset {
+		clause += " OFFSET ?"
+		args = append(args, p.Offset)
+	}
+
+	return
+}
+
+// count returns the number of rows of a table.
+func count(ctx context.Context, q Querier, table string) (n int, err error) {
+	err = q.QueryRowContext(ctx, "SELECT COUNT(*) FROM `"+table+"`").Scan(&n)
+	return
+}
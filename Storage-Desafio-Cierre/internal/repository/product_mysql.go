@@ -1,26 +1,35 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 
 	"app/internal"
 )
 
 // NewProductsMySQL creates new mysql repository for product entity.
-func NewProductsMySQL(db *sql.DB) *ProductsMySQL {
+// The db can be a *sql.DB or a *sql.Tx.
+func NewProductsMySQL(db Querier) *ProductsMySQL {
 	return &ProductsMySQL{db}
 }
 
 // ProductsMySQL is the MySQL repository implementation for product entity.
 type ProductsMySQL struct {
-	// db is the database connection.
-	db *sql.DB
+	// db is the database connection or transaction.
+	db Querier
 }
 
-// FindAll returns all products from the database.
-func (r *ProductsMySQL) FindAll() (p []internal.Product, err error) {
+// FindAll returns the products of a page from the database.
+func (r *ProductsMySQL) FindAll(ctx context.Context, page internal.Page) (p []internal.Product, err error) {
+	// build the query
+	clause, args, err := pageClause(page, internal.ProductSortFields)
+	if err != nil {
+		return
+	}
+
 	// execute the query
-	rows, err := r.db.Query("SELECT `id`, `description`, `price` FROM products")
+	rows, err := r.db.QueryContext(ctx, "SELECT `id`, `description`, `price`, `stock` FROM products"+clause, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -30,7 +39,7 @@ func (r *ProductsMySQL) FindAll() (p []internal.Product, err error) {
 	for rows.Next() {
 		var pr internal.Product
 		// scan the row into the product
-		err := rows.Scan(&pr.Id, &pr.Description, &pr.Price)
+		err := rows.Scan(&pr.Id, &pr.Description, &pr.Price, &pr.Stock)
 		if err != nil {
 			return nil, err
 		}
@@ -45,36 +54,145 @@ func (r *ProductsMySQL) FindAll() (p []internal.Product, err error) {
 	return
 }
 
-// Save saves the product into the database.
-func (r *ProductsMySQL) Save(p *internal.Product) (err error) {
+// Count returns the number of products in the database.
+func (r *ProductsMySQL) Count(ctx context.Context) (n int, err error) {
+	n, err = count(ctx, r.db, "products")
+	return
+}
+
+// Save saves the product into the database and, in the same transaction,
+// records its stock as the initial stock movement.
+// A non-zero id is preserved, otherwise the database assigns one.
+func (r *ProductsMySQL) Save(ctx context.Context, p *internal.Product) (err error) {
+	err = transaction(ctx, r.db, func(tx Querier) (err error) {
+		// execute the query
+		res, err := exec(ctx, tx,
+			"INSERT INTO products (`id`, `description`, `price`, `stock`) VALUES (?, ?, ?, 0)",
+			nullableId((*p).Id), (*p).Description, (*p).Price,
+		)
+		if err != nil {
+			return err
+		}
+
+		// get the last inserted id
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		// set the id
+		(*p).Id = int(id)
+
+		// stock
+		if (*p).Stock == 0 {
+			return
+		}
+		err = moveStock(ctx, tx, &internal.StockMovement{
+			StockMovementAttributes: internal.StockMovementAttributes{
+				ProductId: (*p).Id,
+				Quantity:  (*p).Stock,
+				Reason:    internal.StockReasonInitial,
+			},
+		})
+		return
+	})
+	return
+}
+
+// SaveAll saves the products in one transaction, only committed if commit is true and every product was saved.
+func (r *ProductsMySQL) SaveAll(ctx context.Context, p []internal.Product, commit bool) (errs []error, err error) {
+	errs, err = saveAll(ctx, r.db, p, commit, func(tx Querier, p *internal.Product) error {
+		return NewProductsMySQL(tx).Save(ctx, p)
+	})
+	return
+}
+
+// FindById returns the product with the given id from the database.
+func (r *ProductsMySQL) FindById(ctx context.Context, id int) (p internal.Product, err error) {
 	// execute the query
-	res, err := r.db.Exec(
-		"INSERT INTO products (`description`, `price`) VALUES (?, ?)",
-		(*p).Description, (*p).Price,
-	)
+	row := r.db.QueryRowContext(ctx, "SELECT `id`, `description`, `price`, `stock` FROM products WHERE `id` = ?", id)
+
+	// scan the row into the product
+	err = row.Scan(&p.Id, &p.Description, &p.Price, &p.Stock)
 	if err != nil {
-		return err
+		if errors.Is(err, sql.ErrNoRows) {
+			err = internal.ErrProductNotFound
+		}
+		return
 	}
 
-	// get the last inserted id
-	id, err := res.LastInsertId()
+	return
+}
+
+// Update updates the product in the database, except its stock which is read back into the product.
+func (r *ProductsMySQL) Update(ctx context.Context, p *internal.Product) (err error) {
+	// execute the query
+	_, err = exec(ctx, r.db,
+		"UPDATE products SET `description` = ?, `price` = ? WHERE `id` = ?",
+		(*p).Description, (*p).Price, (*p).Id,
+	)
 	if err != nil {
-		return err
+		return
 	}
 
-	// set the id
-	(*p).Id = int(id)
+	// get the stock, which also checks the product exists
+	// as MySQL does not count unchanged rows
+	err = r.db.QueryRowContext(ctx, "SELECT `stock` FROM products WHERE `id` = ?", (*p).Id).Scan(&(*p).Stock)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = internal.ErrProductNotFound
+	}
 
 	return
 }
 
-func (r *ProductsMySQL) FindTopProductsByAmount(limit int) ([]internal.ProductAmount, error) {
+// Delete deletes the product from the database if it has no sales, in a single statement
+// so a sale saved meanwhile is not removed by the cascade.
+// The foreign keys cascade the delete to its stock movements.
+func (r *ProductsMySQL) Delete(ctx context.Context, id int) (err error) {
+	// execute the query
+	res, err := exec(ctx, r.db,
+		"DELETE FROM products WHERE `id` = ? AND NOT EXISTS (SELECT 1 FROM sales WHERE `product_id` = ?)",
+		id, id,
+	)
+	if err != nil {
+		return
+	}
+
+	// check the product existed and had no sales
+	n, err := res.RowsAffected()
+	if err != nil || n > 0 {
+		return
+	}
+	ok, err := exists(ctx, r.db, "products", id)
+	if err != nil {
+		return
+	}
+	err = internal.ErrProductNotFound
+	if ok {
+		err = internal.ErrProductHasSales
+	}
+	return
+}
+
+// FindTopProductsByAmount returns the products with sales in the invoices of the filter of the query ranked by
+// the sum of their sold quantity or of quantity * unit price, up to its limit.
+// The share is the revenue of the product over the one of every product sold in those invoices.
+func (r *ProductsMySQL) FindTopProductsByAmount(ctx context.Context, q internal.TopProductsQuery) ([]internal.ProductAmount, error) {
 	var productsAmount []internal.ProductAmount
-	rows, err := r.db.Query(
-		"SELECT p.`description`, SUM(s.`quantity`) AS `total` "+
-			"FROM products as p INNER JOIN sales as s ON p.`id` = s.`product_id` "+
-			"GROUP BY p.`id` ORDER BY `total` DESC LIMIT ?",
-		limit,
+	where, args := reportWhere(q.ReportFilter)
+	// - left joins: without a filter the sales of invoices without a customer are ranked too
+	joins := "products as p INNER JOIN sales as s ON p.`id` = s.`product_id` " +
+		"LEFT JOIN invoices as i ON s.`invoice_id` = i.`id` LEFT JOIN customers as c ON i.`customer_id` = c.`id`"
+	order := "`units` DESC, `revenue` DESC"
+	if q.RankBy == internal.RankByRevenue {
+		order = "`revenue` DESC, `units` DESC"
+	}
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT p.`description`, SUM(s.`quantity`) AS `units`, SUM(s.`quantity` * s.`unit_price`) AS `revenue`, "+
+			"COALESCE(SUM(s.`quantity` * s.`unit_price`) / MAX(t.`revenue`), 0) AS `share` "+
+			"FROM "+joins+" CROSS JOIN (SELECT SUM(s.`quantity` * s.`unit_price`) AS `revenue` FROM "+joins+where+") as t"+where+" "+
+			"GROUP BY p.`id` ORDER BY "+order+", p.`id` LIMIT ?",
+		append(append(args, args...), q.Limit)...,
 	)
 	if err != nil {
 		return nil, err
@@ -83,7 +201,7 @@ func (r *ProductsMySQL) FindTopProductsByAmount(limit int) ([]internal.ProductAm
 
 	for rows.Next() {
 		var pr internal.ProductAmount
-		err := rows.Scan(&pr.Description, &pr.Total)
+		err := rows.Scan(&pr.Description, &pr.Units, &pr.Revenue, &pr.Share)
 		if err != nil {
 			return nil, err
 		}
@@ -96,3 +214,149 @@ func (r *ProductsMySQL) FindTopProductsByAmount(limit int) ([]internal.ProductAm
 
 	return productsAmount, nil
 }
+
+// FindBasketStats returns the counts of the invoices of the filter with sales of each product and pair of products.
+func (r *ProductsMySQL) FindBasketStats(ctx context.Context, f internal.ReportFilter) (s internal.BasketStats, err error) {
+	where, args := reportWhere(f)
+	// - left join: without a condition filter the invoices without a customer are counted too
+	invoices := "INNER JOIN invoices as i ON s.`invoice_id` = i.`id` LEFT JOIN customers as c ON i.`customer_id` = c.`id`" + where
+
+	// invoices with sales
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(DISTINCT s.`invoice_id`) FROM sales as s "+invoices, args...).Scan(&s.Invoices)
+	if err != nil {
+		return
+	}
+
+	// invoices of each product
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT p.`id`, p.`description`, COUNT(DISTINCT s.`invoice_id`) "+
+			"FROM products as p INNER JOIN sales as s ON p.`id` = s.`product_id` "+invoices+" "+
+			"GROUP BY p.`id` ORDER BY p.`id`",
+		args...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p internal.BasketProduct
+		err = rows.Scan(&p.ProductId, &p.Description, &p.Invoices)
+		if err != nil {
+			return
+		}
+		s.Products = append(s.Products, p)
+	}
+	err = rows.Err()
+	if err != nil {
+		return
+	}
+
+	// invoices of each pair, the sales s are the ones of the first product
+	rows, err = r.db.QueryContext(ctx,
+		"SELECT s.`product_id`, w.`product_id`, COUNT(DISTINCT s.`invoice_id`) "+
+			"FROM sales as s INNER JOIN sales as w ON s.`invoice_id` = w.`invoice_id` AND s.`product_id` < w.`product_id` "+invoices+" "+
+			"GROUP BY s.`product_id`, w.`product_id` ORDER BY s.`product_id`, w.`product_id`",
+		args...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p internal.BasketPair
+		err = rows.Scan(&p.ProductId, &p.WithProductId, &p.Invoices)
+		if err != nil {
+			return
+		}
+		s.Pairs = append(s.Pairs, p)
+	}
+	err = rows.Err()
+	return
+}
+
+// AdjustStock adds the quantity of the movement to the stock of its product
+// and records the movement in the same transaction.
+func (r *ProductsMySQL) AdjustStock(ctx context.Context, m *internal.StockMovement) (err error) {
+	err = transaction(ctx, r.db, func(tx Querier) (err error) {
+		err = moveStock(ctx, tx, m)
+		return
+	})
+	return
+}
+
+// FindStockMovements returns the stock movements of a product of a page from the database.
+func (r *ProductsMySQL) FindStockMovements(ctx context.Context, productId int, page internal.Page) (m []internal.StockMovement, err error) {
+	// build the query
+	clause, args, err := pageClause(page, internal.StockMovementSortFields)
+	if err != nil {
+		return
+	}
+
+	// execute the query: the movements of the product are a derived table, so the page clause applies as is
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT `id`, `product_id`, `quantity`, `reason`, `sale_id`, `balance`, `datetime` FROM "+
+			"(SELECT `id`, `product_id`, `quantity`, `reason`, COALESCE(`sale_id`, 0) AS `sale_id`, `balance`, `datetime` "+
+			"FROM stock_movements WHERE `product_id` = ?) AS m"+clause,
+		append([]any{productId}, args...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// iterate over the rows
+	for rows.Next() {
+		var mv internal.StockMovement
+		// scan the row into the movement
+		err := rows.Scan(&mv.Id, &mv.ProductId, &mv.Quantity, &mv.Reason, &mv.SaleId, &mv.Balance, &mv.Datetime)
+		if err != nil {
+			return nil, err
+		}
+		// append the movement to the slice
+		m = append(m, mv)
+	}
+	err = rows.Err()
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// CountStockMovements returns the number of stock movements of a product in the database.
+func (r *ProductsMySQL) CountStockMovements(ctx context.Context, productId int) (n int, err error) {
+	row := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM stock_movements WHERE `product_id` = ?", productId)
+	err = row.Scan(&n)
+	return
+}
+
+// FindLowStock returns the products with a stock up to threshold from the database, ordered by stock and id.
+func (r *ProductsMySQL) FindLowStock(ctx context.Context, threshold int) (p []internal.Product, err error) {
+	// execute the query
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT `id`, `description`, `price`, `stock` FROM products WHERE `stock` <= ? ORDER BY `stock`, `id`",
+		threshold,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// iterate over the rows
+	for rows.Next() {
+		var pr internal.Product
+		// scan the row into the product
+		err := rows.Scan(&pr.Id, &pr.Description, &pr.Price, &pr.Stock)
+		if err != nil {
+			return nil, err
+		}
+		// append the product to the slice
+		p = append(p, pr)
+	}
+	err = rows.Err()
+	if err != nil {
+		return
+	}
+
+	return
+}
@@ -0,0 +1,130 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"app/internal"
+	"app/internal/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductsMySQL_Delete(t *testing.T) {
+	t.Run("should delete a product without sales", func(t *testing.T) {
+		rp := repository.NewProductsMySQL(OpenTestDB(t))
+
+		err := rp.Delete(context.Background(), 3)
+
+		assert.NoError(t, err)
+		_, err = rp.FindById(context.Background(), 3)
+		assert.ErrorIs(t, err, internal.ErrProductNotFound)
+	})
+
+	t.Run("should refuse to delete a product with sales", func(t *testing.T) {
+		rp := repository.NewProductsMySQL(OpenTestDB(t))
+
+		err := rp.Delete(context.Background(), 1)
+
+		assert.ErrorIs(t, err, internal.ErrProductHasSales)
+		_, err = rp.FindById(context.Background(), 1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return not found", func(t *testing.T) {
+		rp := repository.NewProductsMySQL(OpenTestDB(t))
+
+		err := rp.Delete(context.Background(), 99)
+
+		assert.ErrorIs(t, err, internal.ErrProductNotFound)
+	})
+}
+
+func TestProductsMySQL_FindTopProductsByAmount(t *testing.T) {
+	t.Run("should rank the sales of every invoice without a filter", func(t *testing.T) {
+		rp := repository.NewProductsMySQL(OpenTestDB(t))
+		q := internal.TopProductsQuery{ReportFilter: internal.ReportFilter{Limit: 5}, RankBy: internal.RankByUnits}
+
+		p, err := rp.FindTopProductsByAmount(context.Background(), q)
+
+		require.NoError(t, err)
+		require.Len(t, p, 2)
+		assert.Equal(t, "Milk", p[0].Description)
+		assert.Equal(t, 6, p[0].Units)
+		assert.Equal(t, internal.Money(1500), p[0].Revenue)
+		assert.InDelta(t, 15.0/17, p[0].Share, 0.0001)
+		assert.Equal(t, "Bread", p[1].Description)
+		assert.Equal(t, 2, p[1].Units)
+		assert.Equal(t, internal.Money(200), p[1].Revenue)
+		assert.InDelta(t, 2.0/17, p[1].Share, 0.0001)
+	})
+
+	t.Run("should only rank the sales of the invoices of the customers of the condition", func(t *testing.T) {
+		rp := repository.NewProductsMySQL(OpenTestDB(t))
+		active := internal.CustomerActive
+		q := internal.TopProductsQuery{ReportFilter: internal.ReportFilter{Limit: 5, Condition: &active}, RankBy: internal.RankByRevenue}
+
+		p, err := rp.FindTopProductsByAmount(context.Background(), q)
+
+		require.NoError(t, err)
+		require.Len(t, p, 2)
+		assert.Equal(t, "Milk", p[0].Description)
+		assert.Equal(t, 3, p[0].Units)
+		assert.Equal(t, internal.Money(750), p[0].Revenue)
+		assert.Equal(t, "Bread", p[1].Description)
+		assert.Equal(t, 1, p[1].Units)
+		assert.Equal(t, internal.Money(100), p[1].Revenue)
+	})
+
+	t.Run("should limit the products of the period", func(t *testing.T) {
+		rp := repository.NewProductsMySQL(OpenTestDB(t))
+		q := internal.TopProductsQuery{ReportFilter: internal.ReportFilter{
+			Limit: 1, From: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+		}, RankBy: internal.RankByRevenue}
+
+		p, err := rp.FindTopProductsByAmount(context.Background(), q)
+
+		require.NoError(t, err)
+		require.Len(t, p, 1)
+		assert.Equal(t, "Milk", p[0].Description)
+		assert.Equal(t, 1, p[0].Units)
+		assert.Equal(t, internal.Money(250), p[0].Revenue)
+		assert.InDelta(t, 2.5/3.5, p[0].Share, 0.0001)
+	})
+}
+
+func TestProductsMySQL_FindBasketStats(t *testing.T) {
+	t.Run("should count every invoice without a filter", func(t *testing.T) {
+		rp := repository.NewProductsMySQL(OpenTestDB(t))
+
+		s, err := rp.FindBasketStats(context.Background(), internal.ReportFilter{})
+
+		expected := internal.BasketStats{
+			Invoices: 4,
+			Products: []internal.BasketProduct{
+				{ProductId: 1, Description: "Milk", Invoices: 3},
+				{ProductId: 2, Description: "Bread", Invoices: 2},
+			},
+			Pairs: []internal.BasketPair{{ProductId: 1, WithProductId: 2, Invoices: 1}},
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, expected, s)
+	})
+
+	t.Run("should only count the invoices of the customers of the condition", func(t *testing.T) {
+		rp := repository.NewProductsMySQL(OpenTestDB(t))
+		inactive := internal.CustomerInactive
+
+		s, err := rp.FindBasketStats(context.Background(), internal.ReportFilter{Condition: &inactive})
+
+		expected := internal.BasketStats{
+			Invoices: 1,
+			Products: []internal.BasketProduct{{ProductId: 1, Description: "Milk", Invoices: 1}},
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, expected, s)
+	})
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"strings"
+
+	"app/internal"
+)
+
+// conditionAt returns the SQL expression of the condition of the customer c at f.ConditionAt and its args:
+// the condition of the last change up to then, otherwise the previous one of the first change after it,
+// otherwise the current one. It is the current condition if f.ConditionAt is zero.
+func conditionAt(f internal.ReportFilter) (expr string, args []any) {
+	if f.ConditionAt.IsZero() {
+		return "c.`condition`", nil
+	}
+	expr = "COALESCE(" +
+		"(SELECT h.`condition` FROM customer_condition_changes h WHERE h.`customer_id` = c.`id` AND h.`datetime` <= ? " +
+		"ORDER BY h.`datetime` DESC, h.`id` DESC LIMIT 1), " +
+		"(SELECT h.`previous` FROM customer_condition_changes h WHERE h.`customer_id` = c.`id` AND h.`datetime` > ? " +
+		"ORDER BY h.`datetime`, h.`id` LIMIT 1), " +
+		"c.`condition`)"
+	args = []any{f.ConditionAt, f.ConditionAt}
+	return
+}
+
+// reportWhere returns the WHERE clause of the filter on the invoices i and their customers c, and its args.
+// It is empty if the filter has no period nor condition.
+func reportWhere(f internal.ReportFilter) (clause string, args []any) {
+	var conds []string
+	// - period
+	if !f.From.IsZero() {
+		conds = append(conds, "i.`datetime` >= ?")
+		args = append(args, f.From)
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, "i.`datetime` < ?")
+		args = append(args, f.To)
+	}
+	// - condition
+	if f.Condition != nil {
+		expr, exprArgs := conditionAt(f)
+		conds = append(conds, expr+" = ?")
+		args = append(append(args, exprArgs...), *f.Condition)
+	}
+
+	if len(conds) > 0 {
+		clause = " WHERE " + strings.Join(conds, " AND ")
+	}
+	return
+}
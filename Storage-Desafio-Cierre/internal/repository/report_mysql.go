@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+
+	"app/internal"
+)
+
+// NewReportsMySQL creates new mysql repository for the reports.
+// The db can be a *sql.DB or a *sql.Tx.
+func NewReportsMySQL(db Querier) *ReportsMySQL {
+	return &ReportsMySQL{db}
+}
+
+// ReportsMySQL is the MySQL repository implementation for the reports.
+type ReportsMySQL struct {
+	// db is the database connection or transaction.
+	db Querier
+}
+
+// bucketStart returns the SQL expression of the start of the bucket of the invoice i for the interval.
+// The datetimes are stored in the store time zone, so the buckets start at its midnight.
+func bucketStart(v internal.RevenueInterval) string {
+	switch v {
+	case internal.RevenueWeek:
+		return "DATE_SUB(DATE(i.`datetime`), INTERVAL WEEKDAY(i.`datetime`) DAY)"
+	case internal.RevenueMonth:
+		return "CAST(DATE_FORMAT(i.`datetime`, '%Y-%m-01') AS DATE)"
+	default:
+		return "DATE(i.`datetime`)"
+	}
+}
+
+// FindRevenue returns the buckets of the query with invoices, ordered by start and condition.
+// The units of each invoice are summed apart so the total of an invoice is counted once.
+func (r *ReportsMySQL) FindRevenue(ctx context.Context, q internal.RevenueQuery) (b []internal.RevenueBucket, err error) {
+	// build the query
+	columns := bucketStart(q.Interval) + " AS `start`"
+	group := "`start`"
+	var args []any
+	if q.ByCondition {
+		condition, conditionArgs := conditionAt(q.ReportFilter)
+		columns += ", " + condition + " AS `condition_at`"
+		group += ", `condition_at`"
+		args = conditionArgs
+	}
+	where, whereArgs := reportWhere(q.ReportFilter)
+	args = append(args, whereArgs...)
+
+	// execute the query
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+columns+", COUNT(*), COALESCE(SUM(u.`units`), 0), COALESCE(SUM(i.`total`), 0) "+
+			"FROM invoices as i INNER JOIN customers as c ON i.`customer_id` = c.`id` "+
+			"LEFT JOIN (SELECT `invoice_id`, SUM(`quantity`) AS `units` FROM sales GROUP BY `invoice_id`) as u ON u.`invoice_id` = i.`id`"+
+			where+" GROUP BY "+group+" ORDER BY "+group,
+		args...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// iterate over the rows
+	for rows.Next() {
+		var bu internal.RevenueBucket
+		dest := []any{&bu.Start}
+		if q.ByCondition {
+			bu.Condition = new(internal.CustomerCondition)
+			dest = append(dest, bu.Condition)
+		}
+		err = rows.Scan(append(dest, &bu.Invoices, &bu.Units, &bu.Revenue)...)
+		if err != nil {
+			return
+		}
+		b = append(b, bu)
+	}
+	err = rows.Err()
+	return
+}
+
+// FindCustomerActivity returns the activity of the customers with invoices of the filter, ordered by id.
+func (r *ReportsMySQL) FindCustomerActivity(ctx context.Context, f internal.ReportFilter) (a []internal.CustomerActivity, err error) {
+	where, args := reportWhere(f)
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT c.`id`, c.`first_name`, c.`last_name`, MAX(i.`datetime`), COUNT(*), COALESCE(SUM(i.`total`), 0) "+
+			"FROM invoices as i INNER JOIN customers as c ON i.`customer_id` = c.`id`"+where+" "+
+			"GROUP BY c.`id` ORDER BY c.`id`",
+		args...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// iterate over the rows
+	for rows.Next() {
+		var ac internal.CustomerActivity
+		err = rows.Scan(&ac.CustomerId, &ac.FirstName, &ac.LastName, nullTime{&ac.LastInvoice}, &ac.Invoices, &ac.Total)
+		if err != nil {
+			return
+		}
+		a = append(a, ac)
+	}
+	err = rows.Err()
+	return
+}
@@ -0,0 +1,96 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"app/internal"
+	"app/internal/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// condition returns a pointer to the condition c
+func condition(c internal.CustomerCondition) *internal.CustomerCondition {
+	return &c
+}
+
+func TestReportsMySQL_FindRevenue(t *testing.T) {
+	t.Run("should count the units and revenue of the invoices of each day", func(t *testing.T) {
+		rp := repository.NewReportsMySQL(OpenTestDB(t))
+		q := internal.RevenueQuery{ReportFilter: internal.ReportFilter{
+			From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+		}, Interval: internal.RevenueDay}
+
+		b, err := rp.FindRevenue(context.Background(), q)
+
+		expected := []internal.RevenueBucket{
+			{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Invoices: 1, Units: 3, Revenue: 600},
+			{Start: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Invoices: 1, Units: 1, Revenue: 250},
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, expected, b)
+	})
+
+	t.Run("should split the weeks by the current condition", func(t *testing.T) {
+		rp := repository.NewReportsMySQL(OpenTestDB(t))
+		q := internal.RevenueQuery{ReportFilter: internal.ReportFilter{
+			From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		}, Interval: internal.RevenueWeek, ByCondition: true}
+
+		b, err := rp.FindRevenue(context.Background(), q)
+
+		expected := []internal.RevenueBucket{
+			{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Condition: condition(internal.CustomerActive), Invoices: 2, Units: 4, Revenue: 850},
+			{Start: time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), Condition: condition(internal.CustomerInactive), Invoices: 1, Units: 3, Revenue: 750},
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, expected, b)
+	})
+
+	t.Run("should split the month by the condition at a datetime", func(t *testing.T) {
+		rp := repository.NewReportsMySQL(OpenTestDB(t))
+		q := internal.RevenueQuery{ReportFilter: internal.ReportFilter{
+			From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			ConditionAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		}, Interval: internal.RevenueMonth, ByCondition: true}
+
+		b, err := rp.FindRevenue(context.Background(), q)
+
+		expected := []internal.RevenueBucket{
+			{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Condition: condition(internal.CustomerActive), Invoices: 3, Units: 7, Revenue: 1600},
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, expected, b)
+	})
+}
+
+func TestReportsMySQL_FindCustomerActivity(t *testing.T) {
+	t.Run("should return the activity of the customers with invoices", func(t *testing.T) {
+		rp := repository.NewReportsMySQL(OpenTestDB(t))
+
+		a, err := rp.FindCustomerActivity(context.Background(), internal.ReportFilter{})
+
+		expected := []internal.CustomerActivity{
+			{CustomerId: 1, FirstName: "Michael", LastName: "Jordan", LastInvoice: time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC), Invoices: 2, Total: 850},
+			{CustomerId: 2, FirstName: "Scottie", LastName: "Pippen", LastInvoice: time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC), Invoices: 1, Total: 750},
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, expected, a)
+	})
+
+	t.Run("should only count the invoices of the period", func(t *testing.T) {
+		rp := repository.NewReportsMySQL(OpenTestDB(t))
+		f := internal.ReportFilter{From: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)}
+
+		a, err := rp.FindCustomerActivity(context.Background(), f)
+
+		expected := []internal.CustomerActivity{
+			{CustomerId: 1, FirstName: "Michael", LastName: "Jordan", LastInvoice: time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC), Invoices: 1, Total: 250},
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, expected, a)
+	})
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"app/internal"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Querier is the set of methods the MySQL repositories need from a connection.
+// Both *sql.DB and *sql.Tx implement it, so a repository can run inside a transaction.
+type Querier interface {
+	// ExecContext executes a query without returning any rows.
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	// QueryContext executes a query that returns rows.
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	// QueryRowContext executes a query that is expected to return at most one row.
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// mysqlErrors maps the MySQL error numbers of constraint failures to the error catalogue of internal.
+var mysqlErrors = map[uint16]error{
+	1062: internal.ErrConflict,         // ER_DUP_ENTRY
+	1217: internal.ErrConflict,         // ER_ROW_IS_REFERENCED
+	1451: internal.ErrConflict,         // ER_ROW_IS_REFERENCED_2
+	1216: internal.ErrInvalidReference, // ER_NO_REFERENCED_ROW
+	1452: internal.ErrInvalidReference, // ER_NO_REFERENCED_ROW_2
+	1048: internal.ErrValidation,       // ER_BAD_NULL_ERROR
+	1264: internal.ErrValidation,       // ER_WARN_DATA_OUT_OF_RANGE
+	1406: internal.ErrValidation,       // ER_DATA_TOO_LONG
+}
+
+// MySQLError is a MySQL error classified into the error catalogue of internal.
+// It matches both its kind and the driver error with errors.Is and errors.As,
+// and its message does not expose the schema.
+type MySQLError struct {
+	// Kind is the error of the catalogue, e.g. internal.ErrConflict.
+	Kind error
+	// Err is the error of the driver.
+	Err *mysql.MySQLError
+}
+
+// Error returns the kind and the MySQL error number.
+func (e *MySQLError) Error() string {
+	return fmt.Sprintf("%s (mysql error %d)", e.Kind, e.Err.Number)
+}
+
+// Unwrap returns the kind and the error of the driver.
+func (e *MySQLError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// translate returns err as a MySQLError if its number is in mysqlErrors, otherwise err as it is.
+func translate(err error) error {
+	var me *mysql.MySQLError
+	if !errors.As(err, &me) {
+		return err
+	}
+	kind, ok := mysqlErrors[me.Number]
+	if !ok {
+		return err
+	}
+	return &MySQLError{Kind: kind, Err: me}
+}
+
+// exec executes a query of q without returning any rows, translating its error with translate.
+func exec(ctx context.Context, q Querier, query string, args ...any) (res sql.Result, err error) {
+	res, err = q.ExecContext(ctx, query, args...)
+	err = translate(err)
+	return
+}
+
+// nullableId returns nil for a zero id so MySQL assigns the next auto increment value,
+// otherwise it returns the id so it is preserved on insert.
+func nullableId(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// nullableString returns nil for an empty string so it is stored as NULL.
+func nullableString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullableTime returns nil for a zero time so it is stored as NULL.
+func nullableTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// nullTime scans a nullable datetime into a time.Time, NULL is the zero time.
+// The invoices created before datetimes were required have a NULL one.
+type nullTime struct {
+	// t is the time scanned into.
+	t *time.Time
+}
+
+// Scan implements the sql.Scanner interface.
+func (n nullTime) Scan(src any) (err error) {
+	var nt sql.NullTime
+	err = nt.Scan(src)
+	if err != nil {
+		return
+	}
+	*n.t = nt.Time
+	return
+}
+
+// exists reports whether a row with the id exists in the table.
+// It is used after an UPDATE that affected no rows, since MySQL does not count unchanged rows.
+func exists(ctx context.Context, q Querier, table string, id int) (ok bool, err error) {
+	row := q.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM `"+table+"` WHERE `id` = ?)", id)
+	err = row.Scan(&ok)
+	return
+}
+
+// errRollback is returned by the function of a transaction to roll it back without an error.
+var errRollback = errors.New("repository: rollback")
+
+// saveAll saves the items with save in one transaction of q, in order, errs has the error of each one.
+// The transaction is rolled back if commit is false or any item fails.
+func saveAll[T any](ctx context.Context, q Querier, items []T, commit bool, save func(tx Querier, item *T) error) (errs []error, err error) {
+	errs = make([]error, len(items))
+	err = transaction(ctx, q, func(tx Querier) (err error) {
+		ok := true
+		for ix := range items {
+			// - a failed statement does not abort the transaction, so the next items are still checked
+			errs[ix] = save(tx, &items[ix])
+			ok = ok && errs[ix] == nil
+		}
+		if !ok || !commit {
+			return errRollback
+		}
+		return
+	})
+	if errors.Is(err, errRollback) {
+		err = nil
+	}
+	return
+}
+
+// transaction runs fn inside a transaction of q and commits it if fn succeeds.
+// If q already is a transaction, fn runs in it and committing is left to its owner.
+func transaction(ctx context.Context, q Querier, fn func(tx Querier) (err error)) (err error) {
+	db, ok := q.(*sql.DB)
+	if !ok {
+		return fn(q)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			// - a canceled context already rolled the transaction back
+			if errRb := tx.Rollback(); errRb != nil && !errors.Is(errRb, sql.ErrTxDone) {
+				err = fmt.Errorf("%w (rollback: %v)", err, errRb)
+			}
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		return
+	}
+	err = tx.Commit()
+	return
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"app/internal"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslate(t *testing.T) {
+	t.Run("should translate the constraint failures into the error catalogue", func(t *testing.T) {
+		cases := map[uint16]error{
+			1062: internal.ErrConflict,
+			1451: internal.ErrConflict,
+			1452: internal.ErrInvalidReference,
+			1406: internal.ErrValidation,
+		}
+		for number, expected := range cases {
+			errDriver := &mysql.MySQLError{Number: number, Message: "schema details"}
+
+			err := translate(fmt.Errorf("saving: %w", errDriver))
+
+			var me *mysql.MySQLError
+			assert.ErrorIs(t, err, expected)
+			assert.True(t, errors.As(err, &me))
+			assert.NotContains(t, err.Error(), "schema details")
+		}
+	})
+
+	t.Run("should keep other errors", func(t *testing.T) {
+		errDriver := &mysql.MySQLError{Number: 1045, Message: "access denied"}
+
+		err := translate(errDriver)
+
+		assert.Equal(t, error(errDriver), err)
+		assert.Nil(t, translate(nil))
+	})
+}
+
+func TestNullTime(t *testing.T) {
+	t.Run("should scan a datetime", func(t *testing.T) {
+		expected := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+		var datetime time.Time
+
+		err := nullTime{&datetime}.Scan(expected)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, datetime)
+	})
+
+	t.Run("should scan null as the zero time", func(t *testing.T) {
+		datetime := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+		err := nullTime{&datetime}.Scan(nil)
+
+		assert.NoError(t, err)
+		assert.True(t, datetime.IsZero())
+	})
+}
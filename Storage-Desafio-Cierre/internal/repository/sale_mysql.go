@@ -1,26 +1,36 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 
 	"app/internal"
 )
 
 // NewSalesMySQL creates new mysql repository for sale entity.
-func NewSalesMySQL(db *sql.DB) *SalesMySQL {
+// The db can be a *sql.DB or a *sql.Tx.
+func NewSalesMySQL(db Querier) *SalesMySQL {
 	return &SalesMySQL{db}
 }
 
 // SalesMySQL is the MySQL repository implementation for sale entity.
 type SalesMySQL struct {
-	// db is the database connection.
-	db *sql.DB
+	// db is the database connection or transaction.
+	db Querier
 }
 
-// FindAll returns all sales from the database.
-func (r *SalesMySQL) FindAll() (s []internal.Sale, err error) {
+// FindAll returns the sales of a page from the database.
+func (r *SalesMySQL) FindAll(ctx context.Context, page internal.Page) (s []internal.Sale, err error) {
+	// build the query
+	clause, args, err := pageClause(page, internal.SaleSortFields)
+	if err != nil {
+		return
+	}
+
 	// execute the query
-	rows, err := r.db.Query("SELECT `id`, `quantity`, `product_id`, `invoice_id` FROM sales")
+	rows, err := r.db.QueryContext(ctx, "SELECT `id`, `quantity`, `product_id`, `invoice_id`, `unit_price` FROM sales"+clause, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -30,7 +40,7 @@ func (r *SalesMySQL) FindAll() (s []internal.Sale, err error) {
 	for rows.Next() {
 		var sa internal.Sale
 		// scan the row into the sale
-		err := rows.Scan(&sa.Id, &sa.Quantity, &sa.ProductId, &sa.InvoiceId)
+		err := rows.Scan(&sa.Id, &sa.Quantity, &sa.ProductId, &sa.InvoiceId, &sa.UnitPrice)
 		if err != nil {
 			return nil, err
 		}
@@ -45,12 +55,186 @@ func (r *SalesMySQL) FindAll() (s []internal.Sale, err error) {
 	return
 }
 
-// Save saves the sale into the database.
-func (r *SalesMySQL) Save(s *internal.Sale) (err error) {
+// FindById returns the sale with the given id from the database.
+func (r *SalesMySQL) FindById(ctx context.Context, id int) (s internal.Sale, err error) {
+	// execute the query
+	row := r.db.QueryRowContext(ctx, "SELECT `id`, `quantity`, `product_id`, `invoice_id`, `unit_price` FROM sales WHERE `id` = ?", id)
+
+	// scan the row into the sale
+	err = row.Scan(&s.Id, &s.Quantity, &s.ProductId, &s.InvoiceId, &s.UnitPrice)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = internal.ErrSaleNotFound
+		}
+		return
+	}
+
+	return
+}
+
+// Count returns the number of sales in the database.
+func (r *SalesMySQL) Count(ctx context.Context) (n int, err error) {
+	n, err = count(ctx, r.db, "sales")
+	return
+}
+
+// Save saves the sale into the database at the current price of its product and, in the same transaction,
+// takes its quantity from the stock of its product and recomputes the total of its invoice.
+// A non-zero id is preserved, otherwise the database assigns one.
+func (r *SalesMySQL) Save(ctx context.Context, s *internal.Sale) (err error) {
+	err = transaction(ctx, r.db, func(tx Querier) (err error) {
+		err = insertSale(ctx, tx, s)
+		if err != nil {
+			return
+		}
+
+		// update the total
+		err = updateInvoiceTotal(ctx, tx, (*s).InvoiceId)
+		return
+	})
+	return
+}
+
+// Update updates the sale in the database and, in the same transaction,
+// moves the stock from the current quantity and product to the new ones and
+// recomputes the total of the invoice it belonged to and the one it belongs to.
+// The sale keeps its unit price unless its product changes, then it takes the current price of the new one.
+func (r *SalesMySQL) Update(ctx context.Context, s *internal.Sale) (err error) {
+	err = transaction(ctx, r.db, func(tx Querier) (err error) {
+		// get the current sale, locking the row
+		current := internal.Sale{Id: (*s).Id}
+		err = tx.QueryRowContext(ctx, "SELECT `quantity`, `product_id`, `invoice_id`, `unit_price` FROM sales WHERE `id` = ? FOR UPDATE", (*s).Id).
+			Scan(&current.Quantity, &current.ProductId, &current.InvoiceId, &current.UnitPrice)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = internal.ErrSaleNotFound
+			}
+			return
+		}
+		invoiceId := current.InvoiceId
+
+		// check the invoice and product exist
+		err = checkSaleReferences(ctx, tx, s)
+		if err != nil {
+			return
+		}
+
+		// unit price
+		(*s).UnitPrice = current.UnitPrice
+		if (*s).ProductId != current.ProductId {
+			err = tx.QueryRowContext(ctx, "SELECT `price` FROM products WHERE `id` = ?", (*s).ProductId).Scan(&(*s).UnitPrice)
+			if err != nil {
+				return
+			}
+		}
+
+		// stock: give the current quantity back before taking the new one
+		err = revertSaleStock(ctx, tx, current)
+		if err != nil {
+			return
+		}
+		err = takeSaleStock(ctx, tx, *s)
+		if err != nil {
+			return
+		}
+
+		// execute the query
+		_, err = exec(ctx, tx,
+			"UPDATE sales SET `quantity` = ?, `product_id` = ?, `invoice_id` = ?, `unit_price` = ? WHERE `id` = ?",
+			(*s).Quantity, (*s).ProductId, (*s).InvoiceId, (*s).UnitPrice, (*s).Id,
+		)
+		if err != nil {
+			return
+		}
+
+		// update the totals
+		err = updateInvoiceTotal(ctx, tx, (*s).InvoiceId)
+		if err != nil {
+			return
+		}
+		if invoiceId != (*s).InvoiceId {
+			err = updateInvoiceTotal(ctx, tx, invoiceId)
+		}
+		return
+	})
+	return
+}
+
+// Delete deletes the sale from the database and, in the same transaction,
+// gives its quantity back to the stock of its product and recomputes the total of its invoice.
+func (r *SalesMySQL) Delete(ctx context.Context, id int) (err error) {
+	err = transaction(ctx, r.db, func(tx Querier) (err error) {
+		// get the sale, locking the row
+		current := internal.Sale{Id: id}
+		err = tx.QueryRowContext(ctx, "SELECT `quantity`, `product_id`, `invoice_id` FROM sales WHERE `id` = ? FOR UPDATE", id).
+			Scan(&current.Quantity, &current.ProductId, &current.InvoiceId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = internal.ErrSaleNotFound
+			}
+			return
+		}
+		invoiceId := current.InvoiceId
+
+		// stock
+		err = revertSaleStock(ctx, tx, current)
+		if err != nil {
+			return
+		}
+
+		// execute the query
+		_, err = exec(ctx, tx, "DELETE FROM sales WHERE `id` = ?", id)
+		if err != nil {
+			return
+		}
+
+		// update the total
+		err = updateInvoiceTotal(ctx, tx, invoiceId)
+		return
+	})
+	return
+}
+
+// checkSaleReferences returns ErrSaleInvalidReference if the invoice or the product of the sale does not exist.
+func checkSaleReferences(ctx context.Context, q Querier, s *internal.Sale) (err error) {
+	ok, err := exists(ctx, q, "invoices", (*s).InvoiceId)
+	if err != nil {
+		return
+	}
+	if !ok {
+		return fmt.Errorf("%w: invoice %d", internal.ErrSaleInvalidReference, (*s).InvoiceId)
+	}
+
+	ok, err = exists(ctx, q, "products", (*s).ProductId)
+	if err != nil {
+		return
+	}
+	if !ok {
+		return fmt.Errorf("%w: product %d", internal.ErrSaleInvalidReference, (*s).ProductId)
+	}
+
+	return
+}
+
+// insertSale checks the references of the sale and inserts it at the current price of its product,
+// setting its id and unit price, then takes its quantity from the stock of the product. It must run in a transaction.
+func insertSale(ctx context.Context, q Querier, s *internal.Sale) (err error) {
+	// check the invoice and product exist
+	err = checkSaleReferences(ctx, q, s)
+	if err != nil {
+		return
+	}
+
+	// unit price
+	err = q.QueryRowContext(ctx, "SELECT `price` FROM products WHERE `id` = ?", (*s).ProductId).Scan(&(*s).UnitPrice)
+	if err != nil {
+		return
+	}
+
 	// execute the query
-	res, err := r.db.Exec(
-		"INSERT INTO sales (`quantity`, `product_id`, `invoice_id`) VALUES (?, ?, ?)",
-		(*s).Quantity, (*s).ProductId, (*s).InvoiceId,
+	res, err := exec(ctx, q,
+		"INSERT INTO sales (`id`, `quantity`, `product_id`, `invoice_id`, `unit_price`) VALUES (?, ?, ?, ?, ?)",
+		nullableId((*s).Id), (*s).Quantity, (*s).ProductId, (*s).InvoiceId, (*s).UnitPrice,
 	)
 	if err != nil {
 		return err
@@ -65,5 +249,7 @@ func (r *SalesMySQL) Save(s *internal.Sale) (err error) {
 	// set the id
 	(*s).Id = int(id)
 
+	// stock
+	err = takeSaleStock(ctx, q, *s)
 	return
-}
\ No newline at end of file
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"app/internal"
+)
+
+// moveStock adds the quantity of the movement to the stock of its product and records the movement,
+// setting its id, datetime and balance. The stock is never left negative: it returns
+// ErrInsufficientStock instead. It must run in a transaction so the stock and the ledger agree.
+func moveStock(ctx context.Context, q Querier, m *internal.StockMovement) (err error) {
+	// update the stock, only if it is enough
+	res, err := exec(ctx, q,
+		"UPDATE products SET `stock` = `stock` + ? WHERE `id` = ? AND `stock` + ? >= 0",
+		(*m).Quantity, (*m).ProductId, (*m).Quantity,
+	)
+	if err != nil {
+		return
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	// get the balance, locking the row
+	err = q.QueryRowContext(ctx, "SELECT `stock` FROM products WHERE `id` = ? FOR UPDATE", (*m).ProductId).Scan(&(*m).Balance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = internal.ErrProductNotFound
+		}
+		return
+	}
+	if n == 0 && (*m).Quantity != 0 {
+		return fmt.Errorf("%w: product %d has %d, %d requested", internal.ErrInsufficientStock, (*m).ProductId, (*m).Balance, -(*m).Quantity)
+	}
+
+	// record the movement
+	(*m).Datetime = time.Now().Truncate(time.Second)
+	res, err = exec(ctx, q,
+		"INSERT INTO stock_movements (`product_id`, `quantity`, `reason`, `sale_id`, `balance`, `datetime`) VALUES (?, ?, ?, ?, ?, ?)",
+		(*m).ProductId, (*m).Quantity, (*m).Reason, nullableId((*m).SaleId), (*m).Balance, (*m).Datetime,
+	)
+	if err != nil {
+		return
+	}
+
+	// get the last inserted id
+	id, err := res.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	// set the id
+	(*m).Id = int(id)
+
+	return
+}
+
+// takeSaleStock takes the quantity of the sale from the stock of its product.
+func takeSaleStock(ctx context.Context, q Querier, s internal.Sale) (err error) {
+	err = moveStock(ctx, q, &internal.StockMovement{
+		StockMovementAttributes: internal.StockMovementAttributes{
+			ProductId: s.ProductId,
+			Quantity:  -s.Quantity,
+			Reason:    internal.StockReasonSale,
+			SaleId:    s.Id,
+		},
+	})
+	return
+}
+
+// revertSaleStock gives the quantity of the sale back to the stock of its product.
+func revertSaleStock(ctx context.Context, q Querier, s internal.Sale) (err error) {
+	err = moveStock(ctx, q, &internal.StockMovement{
+		StockMovementAttributes: internal.StockMovementAttributes{
+			ProductId: s.ProductId,
+			Quantity:  s.Quantity,
+			Reason:    internal.StockReasonSaleReverted,
+			SaleId:    s.Id,
+		},
+	})
+	return
+}
+
+// revertSalesStock gives the quantity of the sales matching the where clause back to the stock of their products,
+// before the sales are deleted by a cascade.
+func revertSalesStock(ctx context.Context, q Querier, where string, args ...any) (err error) {
+	// get the sales, locking the rows
+	rows, err := q.QueryContext(ctx, "SELECT `id`, `quantity`, `product_id` FROM sales WHERE "+where+" ORDER BY `id` FOR UPDATE", args...)
+	if err != nil {
+		return
+	}
+	var sales []internal.Sale
+	for rows.Next() {
+		var sa internal.Sale
+		err = rows.Scan(&sa.Id, &sa.Quantity, &sa.ProductId)
+		if err != nil {
+			rows.Close()
+			return
+		}
+		sales = append(sales, sa)
+	}
+	rows.Close()
+	err = rows.Err()
+	if err != nil {
+		return
+	}
+
+	// revert the stock, once the rows are closed as the connection is shared
+	for _, sa := range sales {
+		err = revertSaleStock(ctx, q, sa)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
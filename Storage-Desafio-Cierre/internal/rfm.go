@@ -0,0 +1,170 @@
+package internal
+
+import "time"
+
+// RFMSegment is the segment of a customer by its recency, frequency and monetary scores.
+type RFMSegment string
+
+const (
+	// RFMChampions bought recently, often and spent the most.
+	RFMChampions RFMSegment = "champions"
+	// RFMLoyal buy often and not long ago.
+	RFMLoyal RFMSegment = "loyal"
+	// RFMNew bought recently for the first times.
+	RFMNew RFMSegment = "new"
+	// RFMPromising bought recently but not yet often or much.
+	RFMPromising RFMSegment = "promising"
+	// RFMNeedsAttention have not bought for a while.
+	RFMNeedsAttention RFMSegment = "needs attention"
+	// RFMAtRisk bought often or much but not for long.
+	RFMAtRisk RFMSegment = "at risk"
+	// RFMDormant bought rarely and not for long.
+	RFMDormant RFMSegment = "dormant"
+)
+
+// RFMSegments are the segments in the order of the report, from the most to the least valuable.
+var RFMSegments = []RFMSegment{RFMChampions, RFMLoyal, RFMNew, RFMPromising, RFMNeedsAttention, RFMAtRisk, RFMDormant}
+
+// Segment returns the segment of the recency, frequency and monetary scores, each from 1 to 3.
+func Segment(recency, frequency, monetary int) RFMSegment {
+	switch {
+	case recency == 3 && frequency+monetary >= 5:
+		return RFMChampions
+	case recency >= 2 && frequency == 3:
+		return RFMLoyal
+	case recency == 3 && frequency == 1:
+		return RFMNew
+	case recency == 3:
+		return RFMPromising
+	case recency == 2:
+		return RFMNeedsAttention
+	case frequency+monetary >= 4:
+		return RFMAtRisk
+	default:
+		return RFMDormant
+	}
+}
+
+// RFMThresholds are the thresholds of the scores, each score is 1, 2 or 3.
+type RFMThresholds struct {
+	// RecencyDays are the days since the last invoice up to which recency scores 3 and 2.
+	RecencyDays [2]int
+	// Frequency are the number of invoices from which frequency scores 2 and 3.
+	Frequency [2]int
+	// Monetary are the totals of the invoices from which monetary scores 2 and 3.
+	Monetary [2]Money
+}
+
+// RFMThresholdsDefault are the thresholds of the report when the request does not set them.
+var RFMThresholdsDefault = RFMThresholds{
+	RecencyDays: [2]int{30, 90},
+	Frequency:   [2]int{2, 5},
+	Monetary:    [2]Money{10000, 50000},
+}
+
+// Validate checks the rules of the thresholds.
+func (t RFMThresholds) Validate() error {
+	return Validate(
+		Rule{"recency", t.RecencyDays[0] >= 0, "can not be negative"},
+		Rule{"recency", t.RecencyDays[0] < t.RecencyDays[1], "must be ascending"},
+		Rule{"frequency", t.Frequency[0] >= 1, "must be positive"},
+		Rule{"frequency", t.Frequency[0] < t.Frequency[1], "must be ascending"},
+		Rule{"monetary", t.Monetary[0] >= 0, "can not be negative"},
+		Rule{"monetary", t.Monetary[0] < t.Monetary[1], "must be ascending"},
+	)
+}
+
+// Score returns the RFM of the activity of a customer at a time.
+// A customer whose last invoice is unknown scores the lowest recency.
+func (t RFMThresholds) Score(a CustomerActivity, at time.Time) (c CustomerRFM) {
+	c.CustomerActivity = a
+	known := !a.LastInvoice.IsZero()
+	if known && at.After(a.LastInvoice) {
+		c.RecencyDays = int(at.Sub(a.LastInvoice).Hours() / 24)
+	}
+
+	c.Recency, c.Frequency, c.Monetary = 1, 1, 1
+	for ix := range t.RecencyDays {
+		if known && c.RecencyDays <= t.RecencyDays[ix] {
+			c.Recency++
+		}
+		if a.Invoices >= t.Frequency[ix] {
+			c.Frequency++
+		}
+		if a.Total >= t.Monetary[ix] {
+			c.Monetary++
+		}
+	}
+	c.Segment = Segment(c.Recency, c.Frequency, c.Monetary)
+	return
+}
+
+// RFMQuery is the query of the RFM report.
+type RFMQuery struct {
+	// ReportFilter is the filter of the invoices, its limit is not used.
+	// The recency is taken at its end, or now if it has none.
+	ReportFilter
+	// Thresholds are the thresholds of the scores.
+	Thresholds RFMThresholds
+}
+
+// Validate checks the rules of the RFM query.
+func (q RFMQuery) Validate() error {
+	return JoinValidation(
+		Validate(
+			Rule{"to", q.From.IsZero() || q.To.IsZero() || q.To.After(q.From), "must be after from"},
+			Rule{"condition", q.Condition == nil || q.Condition.Valid(), "must be active or inactive"},
+		),
+		q.Thresholds.Validate(),
+	)
+}
+
+// CustomerActivity is the activity of a customer in the invoices of a report.
+type CustomerActivity struct {
+	// CustomerId is the id of the customer.
+	CustomerId int
+	// FirstName is the first name of the customer.
+	FirstName string
+	// LastName is the last name of the customer.
+	LastName string
+	// LastInvoice is the datetime of the last invoice of the customer, zero if its invoices have no datetime.
+	LastInvoice time.Time
+	// Invoices is the number of invoices of the customer.
+	Invoices int
+	// Total is the sum of the total of the invoices of the customer.
+	Total Money
+}
+
+// CustomerRFM is the recency, frequency and monetary value of a customer and their scores.
+type CustomerRFM struct {
+	// CustomerActivity is the activity the scores are computed from.
+	CustomerActivity
+	// RecencyDays is the number of whole days since the last invoice, zero if it is unknown.
+	RecencyDays int
+	// Recency is the recency score, from 1 to 3, 1 if the last invoice is unknown.
+	Recency int
+	// Frequency is the frequency score, from 1 to 3.
+	Frequency int
+	// Monetary is the monetary score, from 1 to 3.
+	Monetary int
+	// Segment is the segment of the scores.
+	Segment RFMSegment
+}
+
+// RFMSegmentSummary is the number of customers of a segment and the total of their invoices.
+type RFMSegmentSummary struct {
+	// Segment is the segment.
+	Segment RFMSegment
+	// Customers is the number of customers of the segment.
+	Customers int
+	// Revenue is the sum of the total of the invoices of the customers of the segment.
+	Revenue Money
+}
+
+// RFMReport is the RFM report: the summary of every segment and the scores of every customer.
+type RFMReport struct {
+	// Segments are the summaries of every segment, in the order of RFMSegments.
+	Segments []RFMSegmentSummary
+	// Customers are the scores of the customers with invoices, ordered by id.
+	Customers []CustomerRFM
+}
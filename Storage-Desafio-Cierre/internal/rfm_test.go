@@ -0,0 +1,33 @@
+package internal_test
+
+import (
+	"app/internal"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRFMThresholds_Score(t *testing.T) {
+	at := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should count the whole days since the last invoice", func(t *testing.T) {
+		a := internal.CustomerActivity{LastInvoice: time.Date(2023, 12, 1, 12, 0, 0, 0, time.UTC), Invoices: 1, Total: 100}
+
+		c := internal.RFMThresholdsDefault.Score(a, at)
+
+		assert.Equal(t, 39, c.RecencyDays)
+		assert.Equal(t, 2, c.Recency)
+		assert.Equal(t, internal.RFMNeedsAttention, c.Segment)
+	})
+
+	t.Run("should score the lowest recency without a last invoice", func(t *testing.T) {
+		a := internal.CustomerActivity{Invoices: 1, Total: 100}
+
+		c := internal.RFMThresholdsDefault.Score(a, at)
+
+		assert.Equal(t, 0, c.RecencyDays)
+		assert.Equal(t, 1, c.Recency)
+		assert.Equal(t, internal.RFMDormant, c.Segment)
+	})
+}
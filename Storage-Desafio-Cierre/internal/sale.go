@@ -8,6 +8,18 @@ type SaleAttributes struct {
 	ProductId int
 	// InvoiceId is the invoice id of the sale.
 	InvoiceId int
+	// UnitPrice is the price of the product when the sale was saved, it is set by the repository
+	// so the total of the invoice does not change with the price of the product.
+	UnitPrice Money
+}
+
+// Validate checks the rules of the sale attributes.
+func (s SaleAttributes) Validate() error {
+	return Validate(
+		Rule{"quantity", s.Quantity > 0, "must be positive"},
+		Rule{"product_id", s.ProductId > 0, "is required"},
+		Rule{"invoice_id", s.InvoiceId > 0, "is required"},
+	)
 }
 
 // Sale is the struct that represents a sale.
@@ -16,4 +28,22 @@ type Sale struct {
 	Id int
 	// SaleAttributes is the attributes of the sale.
 	SaleAttributes
-}
\ No newline at end of file
+}
+
+// SaleSortFields are the fields a list of sales can be sorted by.
+var SaleSortFields = []string{"id", "quantity", "product_id", "invoice_id"}
+
+// SortValue returns the value of a sort field of the sale, or nil if it is not a sort field.
+func (s Sale) SortValue(field string) (value any) {
+	switch field {
+	case "id":
+		value = s.Id
+	case "quantity":
+		value = s.Quantity
+	case "product_id":
+		value = s.ProductId
+	case "invoice_id":
+		value = s.InvoiceId
+	}
+	return
+}
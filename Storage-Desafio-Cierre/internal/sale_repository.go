@@ -1,9 +1,29 @@
 package internal
 
+import (
+	"context"
+	"fmt"
+)
+
+var (
+	// ErrSaleNotFound is returned when a sale does not exist.
+	ErrSaleNotFound = fmt.Errorf("sale %w", ErrNotFound)
+	// ErrSaleInvalidReference is returned when a sale references an invoice or a product that does not exist.
+	ErrSaleInvalidReference = fmt.Errorf("%w: sale references an invoice or product that does not exist", ErrInvalidReference)
+)
+
 // RepositorySale is the interface that wraps the basic Sale methods.
 type RepositorySale interface {
-	// FindAll returns all sales.
-	FindAll() (s []Sale, err error)
-	// Save saves a sale.
-	Save(s *Sale) (err error)
-}
\ No newline at end of file
+	// FindAll returns the sales of a page
+	FindAll(ctx context.Context, page Page) (s []Sale, err error)
+	// Count returns the number of sales
+	Count(ctx context.Context) (n int, err error)
+	// FindById returns the sale with the given id.
+	FindById(ctx context.Context, id int) (s Sale, err error)
+	// Save saves a sale and recomputes the total of its invoice.
+	Save(ctx context.Context, s *Sale) (err error)
+	// Update updates a sale and recomputes the total of the invoices it belonged to and belongs to.
+	Update(ctx context.Context, s *Sale) (err error)
+	// Delete deletes a sale and recomputes the total of its invoice.
+	Delete(ctx context.Context, id int) (err error)
+}
@@ -1,9 +1,19 @@
 package internal
 
+import "context"
+
 // ServiceSale is the interface that wraps the basic ServiceSale methods.
 type ServiceSale interface {
-	// FindAll returns all sales.
-	FindAll() (s []Sale, err error)
-	// Save saves a sale.
-	Save(s *Sale) (err error)
-}
\ No newline at end of file
+	// FindAll returns the sales of a page
+	FindAll(ctx context.Context, page Page) (s []Sale, err error)
+	// Count returns the number of sales
+	Count(ctx context.Context) (n int, err error)
+	// FindById returns a sale by id.
+	FindById(ctx context.Context, id int) (s Sale, err error)
+	// Save saves a sale, keeping its invoice total consistent.
+	Save(ctx context.Context, s *Sale) (err error)
+	// Update updates a sale, keeping its invoice total consistent.
+	Update(ctx context.Context, s *Sale) (err error)
+	// Delete deletes a sale, keeping its invoice total consistent.
+	Delete(ctx context.Context, id int) (err error)
+}
@@ -1,6 +1,9 @@
 package service
 
-import "app/internal"
+import (
+	"app/internal"
+	"context"
+)
 
 // NewCustomersDefault creates new default service for customer entity.
 func NewCustomersDefault(rp internal.RepositoryCustomer) *CustomersDefault {
@@ -13,25 +16,110 @@ type CustomersDefault struct {
 	rp internal.RepositoryCustomer
 }
 
-// FindAll returns all customers.
-func (s *CustomersDefault) FindAll() (c []internal.Customer, err error) {
-	c, err = s.rp.FindAll()
+// FindAll returns the customers of a page.
+func (s *CustomersDefault) FindAll(ctx context.Context, page internal.Page) (c []internal.Customer, err error) {
+	c, err = s.rp.FindAll(ctx, page)
 	return
 }
 
-// Save saves the customer.
-func (s *CustomersDefault) Save(c *internal.Customer) (err error) {
-	err = s.rp.Save(c)
+// Count returns the number of customers.
+func (s *CustomersDefault) Count(ctx context.Context) (n int, err error) {
+	n, err = s.rp.Count(ctx)
 	return
 }
 
-func (s *CustomersDefault) FindTopActiveCustomersByAmountSpent(limit int) (c []internal.CustomerSpent, err error) {
-	c, err = s.rp.FindTopActiveCustomersByAmountSpent(limit)
+// Import saves the customers of the rows of an import that are valid, as the options set.
+func (s *CustomersDefault) Import(ctx context.Context, rows []internal.ImportRow[internal.Customer], o internal.ImportOptions) (r internal.ImportResult, err error) {
+	r, err = importRows(ctx, rows, o,
+		func(c internal.Customer) error { return c.CustomerAttributes.Validate() },
+		s.rp.Save,
+		s.rp.SaveAll,
+	)
 	return
 }
 
-// FindInvoicesByCondition returns the total invoices by customer condition.
-func (s *CustomersDefault) FindInvoicesByCondition() (c []internal.CustomerInvoicesByCondition, err error) {
-	c, err = s.rp.FindInvoicesByCondition()
+// FindById returns the customer with the given id.
+func (s *CustomersDefault) FindById(ctx context.Context, id int) (c internal.Customer, err error) {
+	c, err = s.rp.FindById(ctx, id)
 	return
-}
\ No newline at end of file
+}
+
+// Save saves the customer, if it is valid.
+func (s *CustomersDefault) Save(ctx context.Context, c *internal.Customer) (err error) {
+	// validate
+	err = (*c).CustomerAttributes.Validate()
+	if err != nil {
+		return
+	}
+
+	err = s.rp.Save(ctx, c)
+	return
+}
+
+// Update updates the customer, if it is valid.
+func (s *CustomersDefault) Update(ctx context.Context, c *internal.Customer) (err error) {
+	// validate
+	err = (*c).CustomerAttributes.Validate()
+	if err != nil {
+		return
+	}
+
+	err = s.rp.Update(ctx, c)
+	return
+}
+
+// Delete deletes the customer, refusing if it has invoices unless cascade is true.
+func (s *CustomersDefault) Delete(ctx context.Context, id int, cascade bool) (err error) {
+	err = s.rp.Delete(ctx, id, cascade)
+	return
+}
+
+// ChangeCondition sets the condition of the customer, if it is valid, recording the change.
+func (s *CustomersDefault) ChangeCondition(ctx context.Context, ch *internal.CustomerConditionChange) (err error) {
+	// validate
+	err = internal.Validate(
+		internal.Rule{Field: "condition", Ok: (*ch).Condition.Valid(), Message: "must be active or inactive"},
+	)
+	if err != nil {
+		return
+	}
+
+	err = s.rp.ChangeCondition(ctx, ch)
+	return
+}
+
+// FindConditionHistory returns the condition changes of the customer ordered by datetime.
+// It returns ErrCustomerNotFound instead of an empty history if the customer does not exist.
+func (s *CustomersDefault) FindConditionHistory(ctx context.Context, id int) (h []internal.CustomerConditionChange, err error) {
+	_, err = s.rp.FindById(ctx, id)
+	if err != nil {
+		return
+	}
+
+	h, err = s.rp.FindConditionHistory(ctx, id)
+	return
+}
+
+// FindTopActiveCustomersByAmountSpent returns the customers ranked by the total of their invoices, if the filter is valid.
+func (s *CustomersDefault) FindTopActiveCustomersByAmountSpent(ctx context.Context, f internal.ReportFilter) (c []internal.CustomerSpent, err error) {
+	// validate
+	err = f.Validate()
+	if err != nil {
+		return
+	}
+
+	c, err = s.rp.FindTopActiveCustomersByAmountSpent(ctx, f)
+	return
+}
+
+// FindInvoicesByCondition returns the total invoices by customer condition, if the filter is valid.
+func (s *CustomersDefault) FindInvoicesByCondition(ctx context.Context, f internal.ReportFilter) (c []internal.CustomerInvoicesByCondition, err error) {
+	// validate
+	err = f.Validate()
+	if err != nil {
+		return
+	}
+
+	c, err = s.rp.FindInvoicesByCondition(ctx, f)
+	return
+}
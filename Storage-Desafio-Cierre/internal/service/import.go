@@ -0,0 +1,79 @@
+package service
+
+import (
+	"app/internal"
+	"context"
+)
+
+// importRows validates the rows without errors and saves the valid ones as the options set:
+//   - atomic: all of them at once with saveAll, none if any row fails
+//   - partial: each one with save, so the ones that fail do not stop the others
+//
+// A dry run saves them at once with saveAll without committing, so they are checked against the storage too.
+// Errors of the storage that are not of a row, see internal.IsRowError, fail the whole import.
+func importRows[T any](
+	ctx context.Context,
+	rows []internal.ImportRow[T],
+	o internal.ImportOptions,
+	validate func(e T) error,
+	save func(ctx context.Context, e *T) error,
+	saveAll func(ctx context.Context, e []T, commit bool) (errs []error, err error),
+) (r internal.ImportResult, err error) {
+	// validate
+	err = o.Validate()
+	if err != nil {
+		return
+	}
+	var valid []int
+	for ix := range rows {
+		if rows[ix].Err == nil {
+			rows[ix].Err = validate(rows[ix].Entity)
+		}
+		if rows[ix].Err == nil {
+			valid = append(valid, ix)
+		}
+	}
+
+	// save
+	saved := true
+	switch {
+	case o.Mode == internal.ImportAtomic && len(valid) < len(rows):
+		saved = false
+	case o.Mode == internal.ImportPartial && !o.DryRun:
+		for _, ix := range valid {
+			rows[ix].Err = save(ctx, &rows[ix].Entity)
+		}
+	default:
+		entities := make([]T, len(valid))
+		for jx, ix := range valid {
+			entities[jx] = rows[ix].Entity
+		}
+		var errs []error
+		errs, err = saveAll(ctx, entities, !o.DryRun)
+		if err != nil {
+			return
+		}
+		for jx, ix := range valid {
+			rows[ix].Entity, rows[ix].Err = entities[jx], errs[jx]
+			saved = saved && (errs[jx] == nil || o.Mode == internal.ImportPartial)
+		}
+	}
+
+	// summarise
+	r.DryRun = o.DryRun
+	for _, row := range rows {
+		switch {
+		case row.Err != nil && !internal.IsRowError(row.Err):
+			err = row.Err
+			return
+		case row.Err != nil:
+			r.Failed++
+			r.Errors = append(r.Errors, internal.ImportRowError{Row: row.Row, Err: row.Err})
+		case saved:
+			r.Inserted++
+		default:
+			r.Skipped++
+		}
+	}
+	return
+}
@@ -1,6 +1,12 @@
 package service
 
-import "app/internal"
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"app/internal"
+)
 
 // NewInvoicesDefault creates new default service for invoice entity.
 func NewInvoicesDefault(rp internal.RepositoryInvoice) *InvoicesDefault {
@@ -13,18 +19,109 @@ type InvoicesDefault struct {
 	rp internal.RepositoryInvoice
 }
 
-// FindAll returns all invoices.
-func (s *InvoicesDefault) FindAll() (i []internal.Invoice, err error) {
-	i, err = s.rp.FindAll()
+// FindAll returns the invoices of a page.
+func (s *InvoicesDefault) FindAll(ctx context.Context, page internal.Page) (i []internal.Invoice, err error) {
+	i, err = s.rp.FindAll(ctx, page)
+	return
+}
+
+// Count returns the number of invoices.
+func (s *InvoicesDefault) Count(ctx context.Context) (n int, err error) {
+	n, err = s.rp.Count(ctx)
+	return
+}
+
+// FindById returns the invoice with the given id.
+func (s *InvoicesDefault) FindById(ctx context.Context, id int) (i internal.Invoice, err error) {
+	i, err = s.rp.FindById(ctx, id)
+	return
+}
+
+// FindDocument returns the invoice with the given id with its customer and lines.
+func (s *InvoicesDefault) FindDocument(ctx context.Context, id int) (d internal.InvoiceDocument, err error) {
+	d, err = s.rp.FindDocument(ctx, id)
+	return
+}
+
+// Save saves the invoice if it is valid, the datetime defaults to now.
+// The total starts at zero and is computed from the sales added to the invoice.
+func (s *InvoicesDefault) Save(ctx context.Context, i *internal.Invoice) (err error) {
+	// defaults
+	(*i).Total = 0
+	if (*i).Datetime.IsZero() {
+		(*i).Datetime = now()
+	}
+
+	// validate
+	err = (*i).InvoiceAttributes.Validate()
+	if err != nil {
+		return
+	}
+
+	err = s.rp.Save(ctx, i)
+	return
+}
+
+// Checkout validates the invoice and its lines and saves them in a single transaction.
+// The total is computed by the repository and the datetime defaults to now.
+func (s *InvoicesDefault) Checkout(ctx context.Context, i *internal.Invoice, sales []internal.Sale) (err error) {
+	// defaults
+	if (*i).Datetime.IsZero() {
+		(*i).Datetime = now()
+	}
+	(*i).Total = 0
+
+	// validate: the lines get the invoice id when they are saved
+	errs := []error{
+		(*i).InvoiceAttributes.Validate(),
+		internal.Validate(internal.Rule{Field: "lines", Ok: len(sales) > 0, Message: "must have at least one line"}),
+	}
+	for ix, sa := range sales {
+		errLine := internal.Validate(
+			internal.Rule{Field: "quantity", Ok: sa.Quantity > 0, Message: "must be positive"},
+			internal.Rule{Field: "product_id", Ok: sa.ProductId > 0, Message: "is required"},
+		)
+		errs = append(errs, internal.PrefixValidation(fmt.Sprintf("lines[%d]", ix), errLine))
+	}
+	err = internal.JoinValidation(errs...)
+	if err != nil {
+		return
+	}
+
+	err = s.rp.SaveWithSales(ctx, i, sales)
 	return
 }
 
-// Save saves the invoice.
-func (s *InvoicesDefault) Save(i *internal.Invoice) (err error) {
-	err = s.rp.Save(i)
+// Update updates the invoice, if it is valid.
+func (s *InvoicesDefault) Update(ctx context.Context, i *internal.Invoice) (err error) {
+	// validate
+	err = (*i).InvoiceAttributes.Validate()
+	if err != nil {
+		return
+	}
+
+	err = s.rp.Update(ctx, i)
 	return
 }
 
-func (s *InvoicesDefault) UpdateTotal() (error) {
-	return s.rp.UpdateTotal()
-}
\ No newline at end of file
+// Delete deletes the invoice and its sales.
+func (s *InvoicesDefault) Delete(ctx context.Context, id int) (err error) {
+	err = s.rp.Delete(ctx, id)
+	return
+}
+
+// UpdateTotal sets the total of every invoice from its sales.
+func (s *InvoicesDefault) UpdateTotal(ctx context.Context) error {
+	return s.rp.UpdateTotal(ctx)
+}
+
+// UpdateTotalById sets the total of the invoice from its sales.
+func (s *InvoicesDefault) UpdateTotalById(ctx context.Context, id int) (i internal.Invoice, err error) {
+	i, err = s.rp.UpdateTotalById(ctx, id)
+	return
+}
+
+// now returns the current time truncated to seconds, the precision of the datetime column.
+func now() time.Time {
+	return time.Now().Truncate(time.Second)
+}
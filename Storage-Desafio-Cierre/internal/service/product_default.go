@@ -1,6 +1,9 @@
 package service
 
-import "app/internal"
+import (
+	"app/internal"
+	"context"
+)
 
 // NewProductsDefault creates new default service for product entity.
 func NewProductsDefault(rp internal.RepositoryProduct) *ProductsDefault {
@@ -13,19 +16,134 @@ type ProductsDefault struct {
 	rp internal.RepositoryProduct
 }
 
-// FindAll returns all products.
-func (s *ProductsDefault) FindAll() (p []internal.Product, err error) {
-	p, err = s.rp.FindAll()
+// FindAll returns the products of a page.
+func (s *ProductsDefault) FindAll(ctx context.Context, page internal.Page) (p []internal.Product, err error) {
+	p, err = s.rp.FindAll(ctx, page)
 	return
 }
 
-// Save saves the product.
-func (s *ProductsDefault) Save(p *internal.Product) (err error) {
-	err = s.rp.Save(p)
+// Count returns the number of products.
+func (s *ProductsDefault) Count(ctx context.Context) (n int, err error) {
+	n, err = s.rp.Count(ctx)
 	return
 }
 
-func (s *ProductsDefault) FindTopProductsByAmount(limit int) (p []internal.ProductAmount, err error) {
-	p, err = s.rp.FindTopProductsByAmount(limit)
+// Import saves the products of the rows of an import that are valid, as the options set.
+func (s *ProductsDefault) Import(ctx context.Context, rows []internal.ImportRow[internal.Product], o internal.ImportOptions) (r internal.ImportResult, err error) {
+	r, err = importRows(ctx, rows, o,
+		func(p internal.Product) error { return p.ProductAttributes.Validate() },
+		s.rp.Save,
+		s.rp.SaveAll,
+	)
 	return
-}
\ No newline at end of file
+}
+
+// FindById returns the product with the given id.
+func (s *ProductsDefault) FindById(ctx context.Context, id int) (p internal.Product, err error) {
+	p, err = s.rp.FindById(ctx, id)
+	return
+}
+
+// Save saves the product, if it is valid.
+func (s *ProductsDefault) Save(ctx context.Context, p *internal.Product) (err error) {
+	// validate
+	err = (*p).ProductAttributes.Validate()
+	if err != nil {
+		return
+	}
+
+	err = s.rp.Save(ctx, p)
+	return
+}
+
+// Update updates the product, if it is valid.
+// A new price only applies to the sales saved or moved to the product after it, existing sales keep their unit price
+// and so their invoices keep their totals.
+func (s *ProductsDefault) Update(ctx context.Context, p *internal.Product) (err error) {
+	// validate
+	err = (*p).ProductAttributes.Validate()
+	if err != nil {
+		return
+	}
+
+	err = s.rp.Update(ctx, p)
+	return
+}
+
+// Delete deletes the product, refusing if it has sales so they are not removed by the cascade.
+// The repository checks the sales and deletes at once, so a sale saved meanwhile is not lost.
+func (s *ProductsDefault) Delete(ctx context.Context, id int) (err error) {
+	err = s.rp.Delete(ctx, id)
+	return
+}
+
+// FindTopProductsByAmount returns the products ranked by the quantity sold or the revenue, if the query is valid.
+func (s *ProductsDefault) FindTopProductsByAmount(ctx context.Context, q internal.TopProductsQuery) (p []internal.ProductAmount, err error) {
+	// validate
+	err = q.Validate()
+	if err != nil {
+		return
+	}
+
+	p, err = s.rp.FindTopProductsByAmount(ctx, q)
+	return
+}
+
+// FindAssociations returns the association rules of the products sold in the same invoices, if the query is valid.
+// It returns ErrProductNotFound if the query is of a product that does not exist.
+func (s *ProductsDefault) FindAssociations(ctx context.Context, q internal.BasketQuery) (a []internal.ProductAssociation, err error) {
+	// validate
+	err = q.Validate()
+	if err != nil {
+		return
+	}
+	if q.ProductId != 0 {
+		_, err = s.rp.FindById(ctx, q.ProductId)
+		if err != nil {
+			return
+		}
+	}
+
+	st, err := s.rp.FindBasketStats(ctx, q.ReportFilter)
+	if err != nil {
+		return
+	}
+	a = st.Associations(q)
+	return
+}
+
+// AdjustStock adds the quantity of the movement to the stock of its product, if the movement is valid.
+func (s *ProductsDefault) AdjustStock(ctx context.Context, m *internal.StockMovement) (err error) {
+	// validate
+	err = (*m).StockMovementAttributes.Validate()
+	if err != nil {
+		return
+	}
+
+	err = s.rp.AdjustStock(ctx, m)
+	return
+}
+
+// FindStockMovements returns the stock movements of a product of a page.
+// It returns ErrProductNotFound instead of an empty page if the product does not exist.
+func (s *ProductsDefault) FindStockMovements(ctx context.Context, productId int, page internal.Page) (m []internal.StockMovement, err error) {
+	_, err = s.rp.FindById(ctx, productId)
+	if err != nil {
+		return
+	}
+
+	m, err = s.rp.FindStockMovements(ctx, productId, page)
+	return
+}
+
+// CountStockMovements returns the number of stock movements of a product.
+func (s *ProductsDefault) CountStockMovements(ctx context.Context, productId int) (n int, err error) {
+	n, err = s.rp.CountStockMovements(ctx, productId)
+	return
+}
+
+// FindLowStock returns the products with a stock up to threshold.
+func (s *ProductsDefault) FindLowStock(ctx context.Context, threshold int) (p []internal.Product, err error) {
+	p, err = s.rp.FindLowStock(ctx, threshold)
+	return
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"app/internal"
+	"context"
+	"time"
+)
+
+// NewReportsDefault creates new default service for the reports.
+func NewReportsDefault(rp internal.RepositoryReport) *ReportsDefault {
+	return &ReportsDefault{rp}
+}
+
+// ReportsDefault is the default service implementation for the reports.
+type ReportsDefault struct {
+	// rp is the repository for the reports.
+	rp internal.RepositoryReport
+}
+
+// FindRevenue returns the revenue of every bucket of the period of the query, if it is valid.
+// The buckets without invoices are filled with zero, for every condition if the query is split by condition.
+func (s *ReportsDefault) FindRevenue(ctx context.Context, q internal.RevenueQuery) (b []internal.RevenueBucket, err error) {
+	// validate
+	err = q.Validate()
+	if err != nil {
+		return
+	}
+
+	found, err := s.rp.FindRevenue(ctx, q)
+	if err != nil {
+		return
+	}
+
+	// fill the buckets
+	type key struct {
+		start     int64
+		condition internal.CustomerCondition
+	}
+	byKey := make(map[key]internal.RevenueBucket, len(found))
+	for _, bu := range found {
+		k := key{start: bu.Start.Unix()}
+		if bu.Condition != nil {
+			k.condition = *bu.Condition
+		}
+		byKey[k] = bu
+	}
+	conditions := []*internal.CustomerCondition{nil}
+	if q.ByCondition {
+		conditions = []*internal.CustomerCondition{q.Condition}
+		if q.Condition == nil {
+			inactive, active := internal.CustomerInactive, internal.CustomerActive
+			conditions = []*internal.CustomerCondition{&inactive, &active}
+		}
+	}
+	for _, start := range q.Starts() {
+		for _, condition := range conditions {
+			k := key{start: start.Unix()}
+			if condition != nil {
+				k.condition = *condition
+			}
+			bu, ok := byKey[k]
+			if !ok {
+				bu = internal.RevenueBucket{Condition: condition}
+			}
+			bu.Start = start
+			b = append(b, bu)
+		}
+	}
+	return
+}
+
+// FindRFM returns the scores and segment of the customers with invoices of the query and the summary of every segment,
+// if the query is valid. The recency is taken at the end of the period of the query, or now if it has none.
+func (s *ReportsDefault) FindRFM(ctx context.Context, q internal.RFMQuery) (r internal.RFMReport, err error) {
+	// validate
+	err = q.Validate()
+	if err != nil {
+		return
+	}
+
+	activity, err := s.rp.FindCustomerActivity(ctx, q.ReportFilter)
+	if err != nil {
+		return
+	}
+
+	// score: at the last instant of the period, as its end is exclusive
+	at := q.To.Add(-time.Nanosecond)
+	if q.To.IsZero() {
+		at = now()
+	}
+	summaries := make(map[internal.RFMSegment]*internal.RFMSegmentSummary, len(internal.RFMSegments))
+	r.Segments = make([]internal.RFMSegmentSummary, len(internal.RFMSegments))
+	for ix, segment := range internal.RFMSegments {
+		r.Segments[ix].Segment = segment
+		summaries[segment] = &r.Segments[ix]
+	}
+	for _, a := range activity {
+		c := q.Thresholds.Score(a, at)
+		summaries[c.Segment].Customers++
+		summaries[c.Segment].Revenue += c.Total
+		r.Customers = append(r.Customers, c)
+	}
+	return
+}
@@ -1,6 +1,9 @@
 package service
 
-import "app/internal"
+import (
+	"app/internal"
+	"context"
+)
 
 // NewSalesDefault creates new default service for sale entity.
 func NewSalesDefault(rp internal.RepositorySale) *SalesDefault {
@@ -13,14 +16,50 @@ type SalesDefault struct {
 	rp internal.RepositorySale
 }
 
-// FindAll returns all sales.
-func (sv *SalesDefault) FindAll() (s []internal.Sale, err error) {
-	s, err = sv.rp.FindAll()
+// FindAll returns the sales of a page.
+func (sv *SalesDefault) FindAll(ctx context.Context, page internal.Page) (s []internal.Sale, err error) {
+	s, err = sv.rp.FindAll(ctx, page)
 	return
 }
 
-// Save saves the sale.
-func (sv *SalesDefault) Save(s *internal.Sale) (err error) {
-	err = sv.rp.Save(s)
+// Count returns the number of sales.
+func (sv *SalesDefault) Count(ctx context.Context) (n int, err error) {
+	n, err = sv.rp.Count(ctx)
 	return
-}
\ No newline at end of file
+}
+
+// FindById returns the sale with the given id.
+func (sv *SalesDefault) FindById(ctx context.Context, id int) (s internal.Sale, err error) {
+	s, err = sv.rp.FindById(ctx, id)
+	return
+}
+
+// Save saves the sale, if it is valid.
+func (sv *SalesDefault) Save(ctx context.Context, s *internal.Sale) (err error) {
+	// validate
+	err = (*s).SaleAttributes.Validate()
+	if err != nil {
+		return
+	}
+
+	err = sv.rp.Save(ctx, s)
+	return
+}
+
+// Update updates the sale, if it is valid.
+func (sv *SalesDefault) Update(ctx context.Context, s *internal.Sale) (err error) {
+	// validate
+	err = (*s).SaleAttributes.Validate()
+	if err != nil {
+		return
+	}
+
+	err = sv.rp.Update(ctx, s)
+	return
+}
+
+// Delete deletes the sale.
+func (sv *SalesDefault) Delete(ctx context.Context, id int) (err error) {
+	err = sv.rp.Delete(ctx, id)
+	return
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// StockReasonInitial is the reason of the movement of the stock a product is created with.
+	StockReasonInitial = "initial"
+	// StockReasonSale is the reason of the movement of a sale, it takes its quantity.
+	StockReasonSale = "sale"
+	// StockReasonSaleReverted is the reason of the movement of a sale that is updated or deleted,
+	// it gives its quantity back.
+	StockReasonSaleReverted = "sale reverted"
+)
+
+// StockMovementAttributes is the struct that represents the attributes of a stock movement.
+type StockMovementAttributes struct {
+	// ProductId is the product id of the movement.
+	ProductId int
+	// Quantity is the quantity added to the stock, negative if it is taken.
+	Quantity int
+	// Reason is the reason of the movement, e.g. StockReasonSale or a free text for adjustments.
+	Reason string
+	// SaleId is the sale id of the movement, zero if it is not of a sale.
+	SaleId int
+	// Datetime is the datetime of the movement.
+	Datetime time.Time
+	// Balance is the stock of the product after the movement.
+	Balance int
+}
+
+// Validate checks the rules of a stock adjustment.
+func (m StockMovementAttributes) Validate() error {
+	return Validate(
+		Rule{"quantity", m.Quantity != 0, "can not be zero"},
+		Rule{"reason", strings.TrimSpace(m.Reason) != "", "is required"},
+		Rule{"reason", utf8.RuneCountInString(m.Reason) <= 45, "must be at most 45 characters"},
+	)
+}
+
+// StockMovement is the struct that represents a change of the stock of a product, an entry of its ledger.
+type StockMovement struct {
+	// Id is the unique identifier of the movement.
+	Id int
+	// StockMovementAttributes is the attributes of the movement.
+	StockMovementAttributes
+}
+
+// StockMovementSortFields are the fields a list of stock movements can be sorted by.
+var StockMovementSortFields = []string{"id", "datetime", "quantity"}
+
+// SortValue returns the value of a sort field of the movement, or nil if it is not a sort field.
+func (m StockMovement) SortValue(field string) (value any) {
+	switch field {
+	case "id":
+		value = m.Id
+	case "datetime":
+		value = m.Datetime
+	case "quantity":
+		value = m.Quantity
+	}
+	return
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FieldError is a rule a field of an entity does not satisfy.
+type FieldError struct {
+	// Field is the name of the field, as in the JSON of the API, e.g. first_name or lines[0].quantity.
+	Field string
+	// Message describes the rule, e.g. is required.
+	Message string
+}
+
+// ValidationError is the error returned when an entity does not satisfy its rules.
+type ValidationError struct {
+	// Fields are the field errors, in the order of the rules.
+	Fields []FieldError
+}
+
+// Error returns the field errors in a single line.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for ix, f := range e.Fields {
+		msgs[ix] = f.Field + " " + f.Message
+	}
+	return fmt.Sprintf("%s: %s", ErrValidation, strings.Join(msgs, ", "))
+}
+
+// Is reports whether target is ErrValidation.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Rule is a validation rule of a field: the field is valid if Ok is true, otherwise Message is reported.
+type Rule struct {
+	// Field is the name of the field.
+	Field string
+	// Ok is the result of the rule.
+	Ok bool
+	// Message describes the rule.
+	Message string
+}
+
+// Validate returns a ValidationError with the rules that are not ok, only the first one of each field,
+// or nil if every rule is ok.
+func Validate(rules ...Rule) (err error) {
+	var fields []FieldError
+	failed := make(map[string]bool)
+	for _, r := range rules {
+		if r.Ok || failed[r.Field] {
+			continue
+		}
+		failed[r.Field] = true
+		fields = append(fields, FieldError{Field: r.Field, Message: r.Message})
+	}
+	if len(fields) > 0 {
+		err = &ValidationError{Fields: fields}
+	}
+	return
+}
+
+// JoinValidation merges the field errors of validation errors into one, e.g. of an invoice and its lines.
+// It returns the first error that is not a ValidationError, or nil if there are no errors.
+func JoinValidation(errs ...error) (err error) {
+	var fields []FieldError
+	for _, e := range errs {
+		if e == nil {
+			continue
+		}
+		var ve *ValidationError
+		if !errors.As(e, &ve) {
+			return e
+		}
+		fields = append(fields, ve.Fields...)
+	}
+	if len(fields) > 0 {
+		err = &ValidationError{Fields: fields}
+	}
+	return
+}
+
+// PrefixValidation returns the validation error with its fields prefixed, e.g. lines[0].
+// Other errors are returned as they are.
+func PrefixValidation(prefix string, err error) error {
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		return err
+	}
+	fields := make([]FieldError, len(ve.Fields))
+	for ix, f := range ve.Fields {
+		fields[ix] = FieldError{Field: prefix + "." + f.Field, Message: f.Message}
+	}
+	return &ValidationError{Fields: fields}
+}